@@ -0,0 +1,243 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/contextwtf/lanyard/merkle"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+func (s *Server) AirdropHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.CreateAirdrop(w, r)
+		return
+	case http.MethodGet:
+		s.GetAirdrop(w, r)
+		return
+	default:
+		s.sendJSONError(r, w, nil, http.StatusMethodNotAllowed, "unsupported method")
+		return
+	}
+}
+
+type createAirdropReq struct {
+	Balances []struct {
+		Address string `json:"address"`
+		Amount  string `json:"amount"`
+	} `json:"balances"`
+}
+
+// The claims format produced by Uniswap's merkle-distributor scripts.
+type airdropResp struct {
+	MerkleRoot hexutil.Bytes           `json:"merkleRoot"`
+	TokenTotal string                  `json:"tokenTotal"`
+	Claims     map[string]airdropClaim `json:"claims"`
+}
+
+type airdropClaim struct {
+	Account string          `json:"account,omitempty"`
+	Index   uint64          `json:"index"`
+	Amount  string          `json:"amount"`
+	Proof   []hexutil.Bytes `json:"proof"`
+}
+
+// Formats i as an even length hex string
+// to match ethers' BigNumber.toHexString.
+func evenHex(i *big.Int) string {
+	h := i.Text(16)
+	if len(h)%2 == 1 {
+		h = "0" + h
+	}
+	return "0x" + h
+}
+
+func newAirdropClaim(c merkle.DistributorClaim, proof [][]byte) airdropClaim {
+	phex := []hexutil.Bytes{}
+	for _, p := range proof {
+		phex = append(phex, p)
+	}
+	return airdropClaim{
+		Index:  c.Index,
+		Amount: evenHex(c.Amount),
+		Proof:  phex,
+	}
+}
+
+func isAirdrop(tr getTreeResp) bool {
+	if !tr.Packed || tr.Salted || len(tr.Ltd) != len(merkle.DistributorLtd) {
+		return false
+	}
+	for i := range tr.Ltd {
+		if tr.Ltd[i] != merkle.DistributorLtd[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) CreateAirdrop(w http.ResponseWriter, r *http.Request) {
+	var (
+		req createAirdropReq
+		ctx = r.Context()
+	)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendBodyError(r, w, err, "invalid request body")
+		return
+	}
+	if len(req.Balances) < 2 {
+		s.sendJSONError(r, w, nil, http.StatusBadRequest, "You must provide at least two values")
+		return
+	}
+	if lerr := s.limits.checkLeafCount(len(req.Balances)); lerr != nil {
+		s.sendCodedError(r, w, nil, lerr.code, CodeLimitExceeded, lerr.msg)
+		return
+	}
+
+	var balances []merkle.Balance
+	for i, b := range req.Balances {
+		if !common.IsHexAddress(b.Address) {
+			s.sendFieldError(r, w, nil, fmt.Sprintf("balances[%d].address", i), fmt.Sprintf("invalid address at index %d", i))
+			return
+		}
+		// ParseBig256 accepts both decimal and 0x prefixed hex
+		amt, ok := math.ParseBig256(b.Amount)
+		if !ok {
+			s.sendFieldError(r, w, nil, fmt.Sprintf("balances[%d].amount", i), fmt.Sprintf("invalid amount at index %d", i))
+			return
+		}
+		balances = append(balances, merkle.Balance{
+			Account: common.HexToAddress(b.Address),
+			Amount:  amt,
+		})
+	}
+
+	d, err := merkle.NewDistributor(balances)
+	if err != nil {
+		s.sendJSONError(r, w, err, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, err = s.insertTree(ctx, d.Tree, TreeRecord{
+		Leaves: d.Leaves,
+		Ltd:    merkle.DistributorLtd,
+		Packed: true,
+	})
+	if err != nil {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "inserting tree")
+		return
+	}
+
+	resp := airdropResp{
+		MerkleRoot: d.Root(),
+		TokenTotal: evenHex(d.Total),
+		Claims:     make(map[string]airdropClaim, len(d.Claims)),
+	}
+	for i := range d.Claims {
+		c, p := d.Proof(i)
+		resp.Claims[c.Account.Hex()] = newAirdropClaim(c, p)
+	}
+	s.sendJSON(r, w, resp)
+}
+
+func (s *Server) GetAirdrop(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		root = r.URL.Query().Get("root")
+	)
+	if root == "" {
+		s.sendFieldError(r, w, nil, "root", "missing root")
+		return
+	}
+
+	ct, err := s.getCachedTree(ctx, common.HexToHash(root))
+	if errors.Is(err, ErrNotFound) {
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeTreeNotFound, "tree not found for root")
+		return
+	} else if err != nil {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting tree")
+		return
+	}
+	if !isAirdrop(ct.r) {
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeNotAirdrop, "tree is not an airdrop")
+		return
+	}
+
+	var (
+		total = new(big.Int)
+		resp  = airdropResp{
+			MerkleRoot: ct.t.Root(),
+			Claims:     make(map[string]airdropClaim, len(ct.r.UnhashedLeaves)),
+		}
+	)
+	for i, l := range ct.r.UnhashedLeaves {
+		c, err := merkle.DecodeDistributorLeaf(l)
+		if err != nil {
+			s.sendJSONError(r, w, err, http.StatusInternalServerError, "decoding leaf")
+			return
+		}
+		total.Add(total, c.Amount)
+		resp.Claims[c.Account.Hex()] = newAirdropClaim(c, ct.t.Proof(i))
+	}
+	resp.TokenTotal = evenHex(total)
+
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	s.sendJSON(r, w, resp)
+}
+
+func (s *Server) GetAirdropClaim(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		root = r.URL.Query().Get("root")
+		addr = r.URL.Query().Get("address")
+	)
+	if root == "" {
+		s.sendFieldError(r, w, nil, "root", "missing root")
+		return
+	}
+	if !common.IsHexAddress(addr) {
+		s.sendFieldError(r, w, nil, "address", "missing or malformed address")
+		return
+	}
+
+	ct, err := s.getCachedTree(ctx, common.HexToHash(root))
+	if errors.Is(err, ErrNotFound) {
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeTreeNotFound, "tree not found")
+		return
+	} else if err != nil {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting tree")
+		return
+	}
+	if !isAirdrop(ct.r) {
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeNotAirdrop, "tree is not an airdrop")
+		return
+	}
+
+	account := common.HexToAddress(addr)
+	for i, l := range ct.r.UnhashedLeaves {
+		c, err := merkle.DecodeDistributorLeaf(l)
+		if err != nil {
+			s.sendJSONError(r, w, err, http.StatusInternalServerError, "decoding leaf")
+			return
+		}
+		if c.Account != account {
+			continue
+		}
+		resp := newAirdropClaim(c, ct.t.Proof(i))
+		resp.Account = c.Account.Hex()
+		w.Header().Set("Cache-Control", "public, max-age=31536000")
+		s.sendJSON(r, w, resp)
+		return
+	}
+
+	s.sendCodedError(r, w, nil, http.StatusNotFound, CodeAccountNotFound, "account not found in airdrop")
+}
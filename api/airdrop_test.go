@@ -0,0 +1,41 @@
+package api
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestEvenHex(t *testing.T) {
+	cases := []struct {
+		i    *big.Int
+		want string
+	}{
+		{big.NewInt(0), "0x00"},
+		{big.NewInt(750), "0x02ee"},
+		{big.NewInt(255), "0xff"},
+	}
+
+	for _, c := range cases {
+		if got := evenHex(c.i); got != c.want {
+			t.Errorf("expected: %s got: %s", c.want, got)
+		}
+	}
+}
+
+func TestIsAirdrop(t *testing.T) {
+	cases := []struct {
+		tr   getTreeResp
+		want bool
+	}{
+		{getTreeResp{Ltd: []string{"uint256", "address", "uint256"}, Packed: true}, true},
+		{getTreeResp{Ltd: []string{"uint256", "address", "uint256"}, Packed: false}, false},
+		{getTreeResp{Ltd: []string{"address", "uint256"}, Packed: true}, false},
+		{getTreeResp{}, false},
+	}
+
+	for _, c := range cases {
+		if got := isAirdrop(c.tr); got != c.want {
+			t.Errorf("%v: expected: %t got: %t", c.tr.Ltd, c.want, got)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -13,7 +14,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 
 	lru "github.com/hashicorp/golang-lru/v2"
-	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/rs/cors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/hlog"
@@ -22,32 +22,61 @@ import (
 )
 
 type Server struct {
-	db   *pgxpool.Pool
-	tlru *lru.Cache[common.Hash, cachedTree]
+	store Store
+	tlru  *lru.Cache[common.Hash, cachedTree]
+
+	// nil unless set by SetRateLimits
+	rateLimits *RateLimitConfig
+	limits     Limits
+	metrics    *metrics
+
+	// nil unless set by SetPurge
+	purge func(ctx context.Context, root []byte) error
 }
 
-func New(db *pgxpool.Pool) *Server {
-	l, err := lru.New[common.Hash, cachedTree](1000)
+func New(store Store) *Server {
+	m := newMetrics()
+	l, err := lru.NewWithEvict(1000, func(common.Hash, cachedTree) {
+		m.cacheEvictions.Inc()
+	})
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to create lru cache")
 	}
-	return &Server{
-		db:   db,
-		tlru: l,
+	s := &Server{
+		store:   store,
+		tlru:    l,
+		limits:  DefaultLimits,
+		metrics: m,
 	}
+	s.registerMetrics()
+	return s
 }
 
 func (s *Server) Handler(env, gitSha string) http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/v1/tree", s.TreeHandler)
+	mux.HandleFunc("/api/v1/tree/import", s.ImportTree)
+	mux.HandleFunc("/api/v1/tree/export", s.ExportTree)
+	mux.HandleFunc("/api/v1/tree/proofs", s.StreamProofs)
+	mux.HandleFunc("/api/v1/tree/metadata", s.MetadataHandler)
+	mux.HandleFunc("/api/v1/tree/owner", s.OwnerAction)
 	mux.HandleFunc("/api/v1/proof", s.GetProof)
+	mux.HandleFunc("/api/v1/proofs", s.GetProofs)
+	mux.HandleFunc("/api/v1/proof/trace", s.GetTrace)
 	mux.HandleFunc("/api/v1/root", s.GetRoot)
 	mux.HandleFunc("/api/v1/roots", s.GetRoot)
+	mux.HandleFunc("/api/v1/consistency", s.GetConsistency)
+	mux.HandleFunc("/api/v1/airdrop", s.AirdropHandler)
+	mux.HandleFunc("/api/v1/airdrop/claim", s.GetAirdropClaim)
+	mux.HandleFunc("/api/v1/limits", s.GetLimits)
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, gitSha)
 	})
 
 	h := http.Handler(mux)
+	h = s.rateLimitHandler(h)
+	h = s.limitBodyHandler(h)
+	h = s.metricsHandler(mux, h)
 	h = versionHandler(h, gitSha)
 	h = hlog.UserAgentHandler("user_agent")(h)
 	h = hlog.RefererHandler("referer")(h)
@@ -85,6 +114,12 @@ func ipFromRequest(r *http.Request) string {
 		}
 	}
 
+	return remoteIP(r)
+}
+
+// The IP address of the connection, ignoring
+// headers that clients can set themselves.
+func remoteIP(r *http.Request) string {
 	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err == nil {
 		return host
@@ -123,15 +158,16 @@ func tracingHandler(env, service, sha string, h http.Handler) http.Handler {
 			return
 		}
 
-		span, ctx := tracing.SpanFromContext(r.Context(), "http.request")
+		ctx := tracing.Extract(r.Context(), r.Header)
+		span, ctx := tracing.StartSpan(ctx, "http.request")
 
 		defer span.Finish()
 		log := zerolog.Ctx(ctx)
 
+		log.UpdateContext(span.LogContext)
 		if env != "" {
 			log.UpdateContext(func(c zerolog.Context) zerolog.Context {
-				return c.Uint64("dd.trace_id", span.Context().TraceID()).
-					Str("dd.service", service).
+				return c.Str("dd.service", service).
 					Str("dd.env", env).
 					Str("dd.version", sha)
 			})
@@ -175,12 +211,23 @@ func (s *statusCapture) Write(b []byte) (int, error) {
 	return s.ResponseWriter.Write(b)
 }
 
+// Flush lets streaming handlers flush
+// through the status capture.
+func (s *statusCapture) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func (s *Server) sendJSON(r *http.Request, w http.ResponseWriter, response any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
+// Responds with the code for the status. Use
+// sendCodedError or sendFieldError for errors that
+// clients need to tell apart.
 func (s *Server) sendJSONError(
 	r *http.Request,
 	w http.ResponseWriter,
@@ -188,25 +235,5 @@ func (s *Server) sendJSONError(
 	code int,
 	customMessage string,
 ) {
-	w.Header().Set("Content-Type", "application/json")
-	if code == http.StatusNotFound && w.Header().Get("Cache-Control") == "" {
-		w.Header().Set("Cache-Control", "no-cache, max-age=0, must-revalidate")
-	}
-
-	// all headers need to be set before this line
-	w.WriteHeader(code)
-
-	if err != nil {
-		log.Ctx(r.Context()).Err(err).Send()
-	}
-
-	message := http.StatusText(code)
-	if customMessage != "" {
-		message = customMessage
-	}
-
-	json.NewEncoder(w).Encode(map[string]any{
-		"error":   true,
-		"message": message,
-	})
+	s.sendCodedError(r, w, err, code, codeForStatus(code), customMessage)
 }
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type getConsistencyResp struct {
+	FirstRoot  hexutil.Bytes   `json:"firstRoot"`
+	SecondRoot hexutil.Bytes   `json:"secondRoot"`
+	FirstSize  int             `json:"firstSize"`
+	SecondSize int             `json:"secondSize"`
+	Proof      []hexutil.Bytes `json:"proof"`
+}
+
+// Reports whether the leaves of the first tree
+// are a prefix of the leaves of the second tree.
+func isPrefix(first, second []hexutil.Bytes) bool {
+	if len(first) > len(second) {
+		return false
+	}
+	for i := range first {
+		if !bytes.Equal(first[i], second[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) GetConsistency(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx    = r.Context()
+		first  = r.URL.Query().Get("first")
+		second = r.URL.Query().Get("second")
+	)
+	if first == "" || second == "" {
+		s.sendJSONError(r, w, nil, http.StatusBadRequest, "missing first or second root")
+		return
+	}
+
+	var trees []cachedTree
+	for _, root := range []string{first, second} {
+		ct, err := s.getCachedTree(ctx, common.HexToHash(root))
+		if errors.Is(err, ErrNotFound) {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+			s.sendCodedError(r, w, nil, http.StatusNotFound, CodeTreeNotFound, "tree not found for root "+root)
+			return
+		} else if err != nil {
+			s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting tree")
+			return
+		}
+		trees = append(trees, ct)
+	}
+
+	if !isPrefix(trees[0].r.UnhashedLeaves, trees[1].r.UnhashedLeaves) {
+		s.sendCodedError(r, w, nil, http.StatusConflict, CodeInconsistent, "second tree does not extend first tree")
+		return
+	}
+
+	var (
+		m    = len(trees[0].r.UnhashedLeaves)
+		phex = []hexutil.Bytes{}
+	)
+	for _, p := range trees[1].t.ConsistencyProof(m) {
+		phex = append(phex, p)
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=31536000")
+	s.sendJSON(r, w, getConsistencyResp{
+		FirstRoot:  trees[0].t.Root(),
+		SecondRoot: trees[1].t.Root(),
+		FirstSize:  m,
+		SecondSize: len(trees[1].r.UnhashedLeaves),
+		Proof:      phex,
+	})
+}
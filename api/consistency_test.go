@@ -0,0 +1,30 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestIsPrefix(t *testing.T) {
+	var (
+		a = hexutil.Bytes{0x01}
+		b = hexutil.Bytes{0x02}
+		c = hexutil.Bytes{0x03}
+	)
+	cases := []struct {
+		first, second []hexutil.Bytes
+		want          bool
+	}{
+		{[]hexutil.Bytes{a, b}, []hexutil.Bytes{a, b, c}, true},
+		{[]hexutil.Bytes{a, b}, []hexutil.Bytes{a, b}, true},
+		{[]hexutil.Bytes{a, c}, []hexutil.Bytes{a, b, c}, false},
+		{[]hexutil.Bytes{a, b, c}, []hexutil.Bytes{a, b}, false},
+	}
+
+	for _, c := range cases {
+		if got := isPrefix(c.first, c.second); got != c.want {
+			t.Errorf("%v %v: expected: %t got: %t", c.first, c.second, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/contextwtf/lanyard/merkle/format"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	mediaTypeCSV       = "text/csv"
+	mediaTypeMultipart = "multipart/form-data"
+
+	// multipart bodies larger than this are
+	// buffered to temporary files while parsing
+	maxMultipartMemory = 32 << 20
+)
+
+// Reads a create tree request from a CSV body or from
+// the "file" field of a multipart form. See [format.ReadCSV]
+// for the CSV layout. packedEncoding and salted are read
+// from the query or the form.
+func csvTreeReq(r *http.Request, mediaType string) (createTreeReq, error) {
+	var (
+		req  createTreeReq
+		body io.Reader = r.Body
+		err  error
+	)
+	if mediaType == mediaTypeMultipart {
+		if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+			return req, fmt.Errorf("invalid multipart form: %w", err)
+		}
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			return req, fmt.Errorf("missing file: %w", err)
+		}
+		defer f.Close()
+		body = f
+	}
+
+	for name, dst := range map[string]*bool{
+		"packedEncoding": &req.Packed,
+		"salted":         &req.Salted,
+	} {
+		v := r.FormValue(name)
+		if v == "" {
+			continue
+		}
+		*dst, err = strconv.ParseBool(v)
+		if err != nil {
+			return req, fmt.Errorf("invalid %s %q", name, v)
+		}
+	}
+
+	leaves, ltd, err := format.ReadCSV(body, req.Packed)
+	if err != nil {
+		return req, err
+	}
+	req.Ltd = ltd
+	req.Leaves = make([]string, 0, len(leaves))
+	for _, l := range leaves {
+		req.Leaves = append(req.Leaves, hexutil.Encode(l))
+	}
+	return req, nil
+}
+
+// Like sendJSONError but lists the invalid
+// cells so they can be fixed in a spreadsheet.
+func (s *Server) sendCSVError(r *http.Request, w http.ResponseWriter, cerr *format.CSVError) {
+	s.writeError(r, w, nil, http.StatusBadRequest, struct {
+		errorResp
+		Rows         []format.RowError `json:"rows"`
+		InvalidCells int               `json:"invalidCells"`
+	}{
+		errorResp:    newErrorResp(r, http.StatusBadRequest, CodeInvalidCSV, "invalid csv"),
+		Rows:         cerr.Rows,
+		InvalidCells: cerr.Total,
+	})
+}
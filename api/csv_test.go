@@ -0,0 +1,98 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/contextwtf/lanyard/merkle/format"
+)
+
+func TestCreateTreeCSV(t *testing.T) {
+	const csv = "address,uint256\n" +
+		"0x0000000000000000000000000000000000000001,1\n" +
+		"0x0000000000000000000000000000000000000002,2\n"
+
+	var (
+		h    = New(NewMemStore()).Handler("test", "")
+		want createTreeResp
+	)
+	code := do(t, h, http.MethodPost, "/api/v1/tree", createTreeReq{
+		Leaves: []string{
+			"0x00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000001",
+			"0x00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000002",
+		},
+		Ltd: []string{"address", "uint256"},
+	}, &want)
+	if code != http.StatusOK {
+		t.Fatalf("create: expected: %d got: %d", http.StatusOK, code)
+	}
+
+	var (
+		mp bytes.Buffer
+		mw = multipart.NewWriter(&mp)
+	)
+	fw, err := mw.CreateFormFile("file", "allowlist.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(csv))
+	mw.WriteField("packedEncoding", "false")
+	mw.Close()
+
+	cases := []struct {
+		name, contentType string
+		body              []byte
+	}{
+		{"csv", "text/csv; charset=utf-8", []byte(csv)},
+		{"multipart", mw.FormDataContentType(), mp.Bytes()},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tree", bytes.NewReader(c.body))
+		req.Header.Set("Content-Type", c.contentType)
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected: %d got: %d %s", c.name, http.StatusOK, w.Code, w.Body)
+			continue
+		}
+		var got createTreeResp
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.MerkleRoot != want.MerkleRoot {
+			t.Errorf("%s: expected: %s got: %s", c.name, want.MerkleRoot, got.MerkleRoot)
+		}
+	}
+}
+
+func TestCreateTreeCSVErrors(t *testing.T) {
+	var (
+		h   = New(NewMemStore()).Handler("test", "")
+		csv = "address,uint8\n" +
+			"0x0000000000000000000000000000000000000001,1\n" +
+			"0x0000000000000000000000000000000000000002,256\n"
+		w   = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/api/v1/tree", strings.NewReader(csv))
+	)
+	req.Header.Set("Content-Type", "text/csv")
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected: %d got: %d", http.StatusBadRequest, w.Code)
+	}
+
+	var resp struct {
+		Rows         []format.RowError `json:"rows"`
+		InvalidCells int               `json:"invalidCells"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.InvalidCells != 1 || len(resp.Rows) != 1 || resp.Rows[0].Row != 3 || resp.Rows[0].Column != 2 {
+		t.Errorf("expected row 3 column 2 got: %+v", resp)
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/contextwtf/lanyard/merkle/format"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// Decodes a leaf into one string per type in ltd.
+// Addresses are checksummed, integers are base 10 and
+// bytes are hex. Returns nil if the leaf cannot be decoded,
+// e.g. packed leaves with dynamic types.
+func decodeLeaf(leaf []byte, ltd []string, packed bool) []string {
+	if len(ltd) == 0 {
+		return nil
+	}
+	args, err := format.Arguments(ltd)
+	if err != nil {
+		return nil
+	}
+	vals, err := format.Unpack(args, leaf, packed)
+	if err != nil {
+		return nil
+	}
+	fields := make([]string, 0, len(vals))
+	for _, v := range vals {
+		fields = append(fields, fmt.Sprint(format.FromABI(v)))
+	}
+	return fields
+}
+
+// Reports whether a decoded field equals the value
+// from a filter. Integers are compared numerically so
+// that 0x10 matches 16 and hex is case insensitive.
+func fieldMatches(field, value string) bool {
+	if strings.EqualFold(field, value) {
+		return true
+	}
+	a, aok := math.ParseBig256(field)
+	b, bok := math.ParseBig256(value)
+	return aok && bok && a.Cmp(b) == 0
+}
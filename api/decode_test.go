@@ -0,0 +1,67 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDecodeLeaf(t *testing.T) {
+	cases := []struct {
+		leaf   string
+		ltd    []string
+		packed bool
+		want   []string
+	}{
+		{
+			"0x00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002",
+			[]string{"address", "uint256"},
+			false,
+			[]string{"0x0000000000000000000000000000000000000001", "2"},
+		},
+		{
+			"0x0000000000000000000000000000000000000001" + "ff" + "01" + "0a0b",
+			[]string{"address", "int8", "bool", "bytes2"},
+			true,
+			[]string{"0x0000000000000000000000000000000000000001", "-1", "true", "0x0a0b"},
+		},
+		{
+			"0x0000000000000000000000000000000000000001",
+			[]string{"address", "string"},
+			true,
+			nil,
+		},
+		{
+			"0x00000000000000000000000000000000000000000001",
+			[]string{"address"},
+			true,
+			nil,
+		},
+	}
+	for _, c := range cases {
+		got := decodeLeaf(common.FromHex(c.leaf), c.ltd, c.packed)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%v %s: expected: %v got: %v", c.ltd, c.leaf, c.want, got)
+		}
+	}
+}
+
+func TestFieldMatches(t *testing.T) {
+	cases := []struct {
+		field, value string
+		want         bool
+	}{
+		{"16", "16", true},
+		{"16", "0x10", true},
+		{"0xAbC", "0xabc", true},
+		{"16", "17", false},
+		{"true", "true", true},
+		{"true", "false", false},
+	}
+	for _, c := range cases {
+		if got := fieldMatches(c.field, c.value); got != c.want {
+			t.Errorf("%s %s: expected: %t got: %t", c.field, c.value, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/hlog"
+	"github.com/rs/zerolog/log"
+)
+
+// An ErrorCode identifies the kind of error in a response
+// so that clients don't need to match messages, which may
+// change. Codes are stable once added.
+type ErrorCode string
+
+// Used when no more specific code applies
+const (
+	CodeBadRequest       ErrorCode = "bad_request"
+	CodeForbidden        ErrorCode = "forbidden"
+	CodeNotFound         ErrorCode = "not_found"
+	CodeConflict         ErrorCode = "conflict"
+	CodeTooLarge         ErrorCode = "too_large"
+	CodeRateLimited      ErrorCode = "rate_limited"
+	CodeInternal         ErrorCode = "internal"
+	CodeMethodNotAllowed ErrorCode = "method_not_allowed"
+)
+
+const (
+	// A request field is missing or malformed.
+	// Details lists the fields.
+	CodeInvalidField ErrorCode = "invalid_field"
+	CodeInvalidCSV   ErrorCode = "invalid_csv"
+
+	// The request or tree exceeds one of the [Limits]
+	CodeLimitExceeded ErrorCode = "limit_exceeded"
+
+	CodeTreeNotFound     ErrorCode = "tree_not_found"
+	CodeLeafNotFound     ErrorCode = "leaf_not_found"
+	CodeProofNotFound    ErrorCode = "proof_not_found"
+	CodeMetadataNotFound ErrorCode = "metadata_not_found"
+	CodeOwnerNotFound    ErrorCode = "owner_not_found"
+	CodeAccountNotFound  ErrorCode = "account_not_found"
+	CodeNotAirdrop       ErrorCode = "not_airdrop"
+
+	CodeNotOwner         ErrorCode = "not_owner"
+	CodeInvalidEditToken ErrorCode = "invalid_edit_token"
+	CodeOwnerConflict    ErrorCode = "owner_conflict"
+	CodeSignatureExpired ErrorCode = "signature_expired"
+	CodeSignatureReplay  ErrorCode = "signature_replay"
+	CodeInconsistent     ErrorCode = "inconsistent_trees"
+)
+
+func codeForStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	default:
+		return CodeInternal
+	}
+}
+
+// Describes an invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+type errorResp struct {
+	Error     bool         `json:"error"`
+	Code      ErrorCode    `json:"code"`
+	Message   string       `json:"message"`
+	RequestID string       `json:"requestId,omitempty"`
+	Details   []FieldError `json:"details,omitempty"`
+}
+
+func newErrorResp(r *http.Request, status int, code ErrorCode, customMessage string) errorResp {
+	message := http.StatusText(status)
+	if customMessage != "" {
+		message = customMessage
+	}
+	resp := errorResp{
+		Error:   true,
+		Code:    code,
+		Message: message,
+	}
+	if id, ok := hlog.IDFromRequest(r); ok {
+		resp.RequestID = id.String()
+	}
+	return resp
+}
+
+// Like sendJSONError with a code that is
+// more specific than the one for the status.
+func (s *Server) sendCodedError(
+	r *http.Request,
+	w http.ResponseWriter,
+	err error,
+	status int,
+	code ErrorCode,
+	customMessage string,
+) {
+	s.writeError(r, w, err, status, newErrorResp(r, status, code, customMessage))
+}
+
+// Responds with a 400 for a missing or malformed field.
+// Fields are named as they are in the request, using
+// [i] for elements of arrays.
+func (s *Server) sendFieldError(r *http.Request, w http.ResponseWriter, err error, field, msg string) {
+	resp := newErrorResp(r, http.StatusBadRequest, CodeInvalidField, msg)
+	resp.Details = []FieldError{{Field: field, Message: msg}}
+	s.writeError(r, w, err, http.StatusBadRequest, resp)
+}
+
+// Like sendFieldError for an error returned by validation.
+// Prefix is prepended to the field of a [*FieldError] for
+// objects that are nested in the request.
+func (s *Server) sendValidationError(r *http.Request, w http.ResponseWriter, err error, prefix string) {
+	var fe *FieldError
+	if errors.As(err, &fe) {
+		s.sendFieldError(r, w, nil, prefix+fe.Field, fe.Message)
+		return
+	}
+	s.sendJSONError(r, w, nil, http.StatusBadRequest, err.Error())
+}
+
+func (s *Server) writeError(r *http.Request, w http.ResponseWriter, err error, status int, resp any) {
+	w.Header().Set("Content-Type", "application/json")
+	if status == http.StatusNotFound && w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "no-cache, max-age=0, must-revalidate")
+	}
+
+	// all headers need to be set before this line
+	w.WriteHeader(status)
+
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Send()
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorCodes(t *testing.T) {
+	h := New(NewMemStore()).Handler("test", "")
+
+	var tree createTreeResp
+	req := createTreeReq{Leaves: []string{"0x01", "0x02"}}
+	if code := do(t, h, http.MethodPost, "/api/v1/tree", req, &tree); code != http.StatusOK {
+		t.Fatalf("create tree: expected: %d got: %d", http.StatusOK, code)
+	}
+
+	cases := []struct {
+		name, method, path, body string
+		status                   int
+		code                     ErrorCode
+		field                    string
+	}{
+		{"tree", "GET", "/api/v1/proof?root=0x01&unhashedLeaf=0x01", "", http.StatusNotFound, CodeTreeNotFound, ""},
+		{"leaf", "GET", "/api/v1/proof?root=" + tree.MerkleRoot + "&unhashedLeaf=0x03", "", http.StatusNotFound, CodeLeafNotFound, ""},
+		{"root", "GET", "/api/v1/tree", "", http.StatusBadRequest, CodeInvalidField, "root"},
+		{"limit", "GET", "/api/v1/tree?root=" + tree.MerkleRoot + "&limit=0", "", http.StatusBadRequest, CodeInvalidField, "limit"},
+		{"metadata", "POST", "/api/v1/tree", `{"unhashedLeaves": ["0x01", "0x02"], "metadata": {"tags": ["a", ""]}}`, http.StatusBadRequest, CodeInvalidField, "metadata.tags[1]"},
+		{"amount", "POST", "/api/v1/airdrop", `{"balances": [{"address": "0x0000000000000000000000000000000000000001", "amount": "1"}, {"address": "0x0000000000000000000000000000000000000002", "amount": "x"}]}`, http.StatusBadRequest, CodeInvalidField, "balances[1].amount"},
+		{"csv", "POST", "/api/v1/tree", "address\nnope\n", http.StatusBadRequest, CodeInvalidCSV, ""},
+		{"method", "DELETE", "/api/v1/tree", "", http.StatusMethodNotAllowed, CodeMethodNotAllowed, ""},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.code == CodeInvalidCSV {
+			r.Header.Set("Content-Type", "text/csv")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != c.status {
+			t.Errorf("%s: expected: %d got: %d %s", c.name, c.status, w.Code, w.Body)
+			continue
+		}
+
+		var resp errorResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Code != c.code {
+			t.Errorf("%s: expected: %s got: %s", c.name, c.code, resp.Code)
+		}
+		if resp.RequestID == "" || resp.RequestID != w.Header().Get("Request-Id") {
+			t.Errorf("%s: expected: %q got: %q", c.name, w.Header().Get("Request-Id"), resp.RequestID)
+		}
+		if c.field == "" {
+			continue
+		}
+		if len(resp.Details) != 1 || resp.Details[0].Field != c.field {
+			t.Errorf("%s: expected: %s got: %+v", c.name, c.field, resp.Details)
+		}
+	}
+}
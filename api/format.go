@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/contextwtf/lanyard/merkle"
+	"github.com/contextwtf/lanyard/merkle/format"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	formatMerkleTreeJS = "merkletreejs"
+	formatOpenZeppelin = "openzeppelin"
+)
+
+type importTreeReq struct {
+	Format string `json:"format"`
+
+	// merkletreejs getHexLayers along with the unhashed leaves
+	Layers [][]string `json:"layers"`
+	Leaves []string   `json:"unhashedLeaves"`
+	Ltd    []string   `json:"leafTypeDescriptor"`
+	Packed bool       `json:"packedEncoding"`
+
+	// OpenZeppelin StandardMerkleTree.dump()
+	Dump *format.StandardDump `json:"dump"`
+}
+
+func (s *Server) ImportTree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendJSONError(r, w, nil, http.StatusMethodNotAllowed, "unsupported method")
+		return
+	}
+
+	var (
+		req importTreeReq
+		ctx = r.Context()
+	)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendBodyError(r, w, err, "invalid request body")
+		return
+	}
+
+	var (
+		tree   merkle.Tree
+		leaves [][]byte
+		ltd    []string
+		packed bool
+		err    error
+	)
+	switch req.Format {
+	case formatMerkleTreeJS:
+		for _, l := range req.Leaves {
+			leaves = append(leaves, common.FromHex(l))
+		}
+		ltd, packed = req.Ltd, req.Packed
+		tree, err = format.FromHexLayers(req.Layers, leaves)
+	case formatOpenZeppelin:
+		if req.Dump == nil {
+			s.sendFieldError(r, w, nil, "dump", "missing dump")
+			return
+		}
+		// leaves are keccak256(abi.encode(value))
+		ltd, packed = bytes32Ltd, true
+		tree, leaves, err = format.FromStandard(req.Dump)
+	default:
+		s.sendFieldError(r, w, nil, "format", "unknown format")
+		return
+	}
+	if errors.Is(err, format.ErrRootMismatch) && req.Format == formatOpenZeppelin && !format.StandardShape(len(req.Dump.Values)) {
+		msg := fmt.Sprintf("a dump of %d values has a different shape than a Lanyard tree and cannot keep its root, "+
+			"the number of values must be a run of ones followed by zeros in binary (e.g. 6, 7, 8, 12, 14, 15 or 16)", len(req.Dump.Values))
+		s.sendFieldError(r, w, err, "dump", msg)
+		return
+	} else if errors.Is(err, format.ErrRootMismatch) {
+		s.sendJSONError(r, w, err, http.StatusBadRequest, "root does not match dump")
+		return
+	} else if err != nil {
+		s.sendJSONError(r, w, err, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(leaves) < 2 {
+		s.sendJSONError(r, w, nil, http.StatusBadRequest, "You must provide at least two values")
+		return
+	}
+	if lerr := s.limits.checkTree(leaves, ltd); lerr != nil {
+		s.sendCodedError(r, w, nil, lerr.code, CodeLimitExceeded, lerr.msg)
+		return
+	}
+
+	_, err = s.insertTree(ctx, tree, TreeRecord{
+		Leaves: leaves,
+		Ltd:    ltd,
+		Packed: packed,
+	})
+	if err != nil {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "inserting tree")
+		return
+	}
+
+	s.sendJSON(r, w, createTreeResp{MerkleRoot: hexutil.Encode(tree.Root())})
+}
+
+func (s *Server) ExportTree(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		root = r.URL.Query().Get("root")
+		f    = r.URL.Query().Get("format")
+	)
+	if root == "" {
+		s.sendFieldError(r, w, nil, "root", "missing root")
+		return
+	}
+
+	ct, err := s.getCachedTree(ctx, common.HexToHash(root))
+	if errors.Is(err, ErrNotFound) {
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeTreeNotFound, "tree not found for root")
+		return
+	} else if err != nil {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting tree")
+		return
+	}
+
+	switch f {
+	case formatMerkleTreeJS:
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		s.sendJSON(r, w, format.ToHexLayers(ct.t))
+	case formatOpenZeppelin:
+		if ct.r.Salted {
+			s.sendJSONError(r, w, nil, http.StatusBadRequest, "salted trees cannot be exported")
+			return
+		}
+		leaves := make([][]byte, 0, len(ct.r.UnhashedLeaves))
+		for _, l := range ct.r.UnhashedLeaves {
+			leaves = append(leaves, l)
+		}
+		d, err := format.ToStandard(leaves, ct.r.Ltd, ct.r.Packed)
+		if err != nil {
+			s.sendJSONError(r, w, err, http.StatusBadRequest, err.Error())
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		s.sendJSON(r, w, d)
+	default:
+		s.sendFieldError(r, w, nil, "format", "unknown format")
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/contextwtf/lanyard/merkle/format"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestImportOpenZeppelin(t *testing.T) {
+	h := New(NewMemStore()).Handler("test", "")
+
+	cases := []struct {
+		n      int
+		status int
+	}{
+		{2, http.StatusOK},
+		{6, http.StatusOK},
+		{7, http.StatusOK},
+		{5, http.StatusBadRequest},
+		{9, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		var values [][]any
+		for i := 1; i <= c.n; i++ {
+			values = append(values, []any{common.BigToAddress(big.NewInt(int64(i))).Hex(), "1"})
+		}
+		d, err := format.NewStandard(values, []string{"address", "uint256"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var resp createTreeResp
+		code := do(t, h, http.MethodPost, "/api/v1/tree/import", importTreeReq{Format: formatOpenZeppelin, Dump: d}, &resp)
+		if code != c.status {
+			t.Errorf("%d: expected: %d got: %d", c.n, c.status, code)
+			continue
+		}
+		if code != http.StatusOK {
+			continue
+		}
+		if resp.MerkleRoot != hexutil.Encode(d.Root()) {
+			t.Errorf("%d: expected: %x got: %s", c.n, d.Root(), resp.MerkleRoot)
+		}
+	}
+}
+
+func TestExportOpenZeppelinPacked(t *testing.T) {
+	h := New(NewMemStore()).Handler("test", "")
+
+	var tree createTreeResp
+	req := createTreeReq{
+		Leaves: []string{
+			"0x00000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000001",
+			"0x00000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000002",
+		},
+		Ltd:    []string{"address", "uint256"},
+		Packed: true,
+	}
+	if code := do(t, h, http.MethodPost, "/api/v1/tree", req, &tree); code != http.StatusOK {
+		t.Fatalf("expected: %d got: %d", http.StatusOK, code)
+	}
+
+	var d format.StandardDump
+	if code := do(t, h, http.MethodGet, "/api/v1/tree/export?format=openzeppelin&root="+tree.MerkleRoot, nil, &d); code != http.StatusOK {
+		t.Fatalf("expected: %d got: %d", http.StatusOK, code)
+	}
+	if err := d.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	want := []any{"0x0000000000000000000000000000000000000001", "1"}
+	if got := d.Values[0].Value; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected: %v got: %v", want, got)
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+// Configures the collector started by [Server.CollectGarbage]
+type GCConfig struct {
+	// How often expired trees are deleted.
+	// Zero disables the collector.
+	Interval time.Duration
+
+	// Trees that have never been read are deleted this
+	// long after they were created. Zero disables pruning.
+	PruneUnread time.Duration
+}
+
+// Number of trees selected for deletion at a time
+const gcBatchSize = 1000
+
+// Deletes expired trees every c.Interval until ctx is done.
+// Trees are deleted along with their proof hashes,
+// metadata and owner.
+func (s *Server) CollectGarbage(ctx context.Context, c GCConfig) {
+	if c.Interval <= 0 {
+		return
+	}
+	t := time.NewTicker(c.Interval)
+	defer t.Stop()
+	for {
+		n, err := s.collect(ctx, time.Now(), c.PruneUnread)
+		if err != nil {
+			log.Ctx(ctx).Err(err).Int("deleted", n).Msg("collecting trees")
+		} else if n > 0 {
+			log.Ctx(ctx).Info().Int("deleted", n).Msg("collected trees")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Deletes the trees that expired before now and, if
+// pruneUnread is set, the trees that have not been read
+// since they were created. Returns the number deleted.
+func (s *Server) collect(ctx context.Context, now time.Time, pruneUnread time.Duration) (int, error) {
+	var unreadBefore time.Time
+	if pruneUnread > 0 {
+		unreadBefore = now.Add(-pruneUnread)
+	}
+	var n int
+	for {
+		roots, err := s.store.ExpiredTrees(ctx, now, unreadBefore, gcBatchSize)
+		if err != nil {
+			return n, fmt.Errorf("selecting expired trees: %w", err)
+		}
+		for _, r := range roots {
+			if err := s.store.DeleteTree(ctx, r); err != nil {
+				return n, fmt.Errorf("deleting tree %x: %w", r, err)
+			}
+			s.tlru.Remove(common.BytesToHash(r))
+			s.purgeTree(ctx, r)
+			n++
+		}
+		if len(roots) < gcBatchSize {
+			return n, nil
+		}
+	}
+}
+
+// Records that the tree was read so that it isn't pruned.
+// Failures are logged rather than failing the read.
+func (s *Server) touchTree(ctx context.Context, root []byte) {
+	if err := s.store.TouchTree(ctx, root); err != nil {
+		log.Ctx(ctx).Err(err).Msg("touching tree")
+	}
+}
+
+// Sets a func that is called after a tree is hidden or
+// deleted by its owner or deleted by the collector. Responses for trees are cached
+// by CDNs for up to a year, so f should purge the tree's
+// responses from any cache in front of the server.
+func (s *Server) SetPurge(f func(ctx context.Context, root []byte) error) {
+	s.purge = f
+}
+
+// Failures are logged since the tree has already
+// been hidden or deleted in the store.
+func (s *Server) purgeTree(ctx context.Context, root []byte) {
+	if s.purge == nil {
+		return
+	}
+	if err := s.purge(ctx, root); err != nil {
+		log.Ctx(ctx).Err(err).Hex("root", root).Msg("purging tree")
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/contextwtf/lanyard/api/migrations"
+)
+
+func TestCollect(t *testing.T) {
+	leaves := []string{
+		"0x000000000000000000000000000000000000000a",
+		"0x000000000000000000000000000000000000000b",
+	}
+	cases := []struct {
+		name        string
+		ttl         int64
+		recreate    bool // without a ttl
+		read        bool
+		after       time.Duration
+		pruneUnread time.Duration
+		kept        bool
+	}{
+		{"not expired", 60, false, false, 0, 0, true},
+		{"expired", 60, false, false, 2 * time.Minute, 0, false},
+		{"extended", 60, true, false, 2 * time.Minute, 0, true},
+		{"unread", 0, false, false, time.Hour, time.Minute, false},
+		{"read", 0, false, true, time.Hour, time.Minute, true},
+		{"unread not pruned", 0, false, false, time.Hour, 0, true},
+		{"unread recently", 0, false, false, time.Hour, 2 * time.Hour, true},
+	}
+	for _, ts := range testStores {
+		t.Run(ts.name, func(t *testing.T) {
+			for _, c := range cases {
+				var (
+					ctx     = context.Background()
+					s       = New(ts.open(t))
+					h       = s.Handler("test", "")
+					created = createTree(t, h, createTreeReq{Leaves: leaves, TTL: c.ttl})
+				)
+				if c.recreate {
+					createTree(t, h, createTreeReq{Leaves: leaves})
+				}
+				var purged bool
+				s.SetPurge(func(context.Context, []byte) error {
+					purged = true
+					return nil
+				})
+				if c.read {
+					do(t, h, http.MethodGet, "/api/v1/proof?root="+created.MerkleRoot+"&unhashedLeaf="+leaves[0], nil, nil)
+				}
+				if _, err := s.collect(ctx, time.Now().Add(c.after), c.pruneUnread); err != nil {
+					t.Fatal(err)
+				}
+				code := do(t, h, http.MethodGet, "/api/v1/tree?root="+created.MerkleRoot, nil, nil)
+				if kept := code == http.StatusOK; kept != c.kept {
+					t.Errorf("%s: expected kept: %t got: %t", c.name, c.kept, kept)
+				}
+				if purged == c.kept {
+					t.Errorf("%s: expected purged: %t got: %t", c.name, !c.kept, purged)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectMigrated(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// a tree from before trees expired
+	if err := migrations.RunSQLite(ctx, db, migrations.SQLite[:4]); err != nil {
+		t.Fatal(err)
+	}
+	const q = `
+		INSERT INTO trees (root, unhashed_leaves, packed, leaf_count, inserted_at)
+		VALUES (x'01', '["0x0a","0x0b"]', true, 2, '2020-01-01 00:00:00');
+		INSERT INTO tree_leaves (root, idx, leaf)
+		VALUES (x'01', 0, x'0a'), (x'01', 1, x'0b');
+	`
+	if _, err := db.ExecContext(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrations.RunSQLite(ctx, db, migrations.SQLite); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(NewSQLiteStore(db))
+	if _, err := s.collect(ctx, time.Now(), 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.store.GetTree(ctx, []byte{0x01}); err != nil {
+		t.Errorf("expected tree to survive collect got: %v", err)
+	}
+}
+
+func TestTreeExpiry(t *testing.T) {
+	for _, ts := range testStores {
+		t.Run(ts.name, func(t *testing.T) {
+			var (
+				h       = New(ts.open(t)).Handler("test", "")
+				created = createTree(t, h, createTreeReq{Leaves: []string{"0x01", "0x02"}, TTL: 60})
+				tree    getTreeResp
+			)
+			do(t, h, http.MethodGet, "/api/v1/tree?root="+created.MerkleRoot, nil, &tree)
+			if tree.ExpiresAt == nil || time.Until(*tree.ExpiresAt) > time.Minute {
+				t.Errorf("expected expiry within a minute got: %v", tree.ExpiresAt)
+			}
+		})
+	}
+}
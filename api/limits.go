@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Limits on the size of requests and of the trees
+// they create. Zero disables a limit.
+type Limits struct {
+	MaxBodyBytes int64 `json:"maxBodyBytes"`
+	MaxLeaves    int   `json:"maxLeaves"`
+	MaxLeafBytes int   `json:"maxLeafBytes"`
+
+	// Maximum number of types in a leaf type descriptor
+	MaxLtdLength int `json:"maxLeafTypeDescriptorLength"`
+}
+
+// Used by [New] unless changed with [Server.SetLimits]
+var DefaultLimits = Limits{
+	MaxBodyBytes: 64 << 20,
+	MaxLeaves:    1000000,
+	MaxLeafBytes: 1024,
+	MaxLtdLength: 32,
+}
+
+func (s *Server) SetLimits(l Limits) {
+	s.limits = l
+}
+
+// The response for a tree that exceeds one of the [Limits]
+type limitError struct {
+	code int
+	msg  string
+}
+
+func (l Limits) checkLeafCount(n int) *limitError {
+	if l.MaxLeaves > 0 && n > l.MaxLeaves {
+		return &limitError{
+			http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("at most %d leaves are allowed", l.MaxLeaves),
+		}
+	}
+	return nil
+}
+
+func (l Limits) checkTree(leaves [][]byte, ltd []string) *limitError {
+	if err := l.checkLeafCount(len(leaves)); err != nil {
+		return err
+	}
+	if l.MaxLtdLength > 0 && len(ltd) > l.MaxLtdLength {
+		return &limitError{
+			http.StatusBadRequest,
+			fmt.Sprintf("leafTypeDescriptor must have at most %d types", l.MaxLtdLength),
+		}
+	}
+	if l.MaxLeafBytes > 0 {
+		for i, leaf := range leaves {
+			if len(leaf) > l.MaxLeafBytes {
+				return &limitError{
+					http.StatusBadRequest,
+					fmt.Sprintf("leaf %d is longer than %d bytes", i, l.MaxLeafBytes),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) limitBodyHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.limits.MaxBodyBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, s.limits.MaxBodyBytes)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Like sendJSONError for bodies that couldn't be read
+// but responds with a 413 if the body was too large.
+func (s *Server) sendBodyError(r *http.Request, w http.ResponseWriter, err error, customMessage string) {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		s.sendJSONError(r, w, nil, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body must be at most %d bytes", mbe.Limit))
+		return
+	}
+	s.sendJSONError(r, w, err, http.StatusBadRequest, customMessage)
+}
+
+// Reports the limits so that clients
+// can check requests before sending them.
+func (s *Server) GetLimits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	s.sendJSON(r, w, s.limits)
+}
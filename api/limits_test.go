@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	s := New(NewMemStore())
+	s.SetLimits(Limits{
+		MaxBodyBytes: 300,
+		MaxLeaves:    3,
+		MaxLeafBytes: 2,
+		MaxLtdLength: 1,
+	})
+	h := s.Handler("test", "")
+
+	cases := []struct {
+		name, body string
+		code       int
+	}{
+		{"ok", `{"unhashedLeaves": ["0x01", "0x02"]}`, http.StatusOK},
+		{"body", `{"unhashedLeaves": ["` + strings.Repeat("0", 300) + `"]}`, http.StatusRequestEntityTooLarge},
+		{"leaves", `{"unhashedLeaves": ["0x01", "0x02", "0x03", "0x04"]}`, http.StatusRequestEntityTooLarge},
+		{"leaf", `{"unhashedLeaves": ["0x01", "0x020304"]}`, http.StatusBadRequest},
+		{"ltd", `{"unhashedLeaves": ["0x01", "0x02"], "leafTypeDescriptor": ["uint8", "uint8"]}`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/tree", strings.NewReader(c.body)))
+		if w.Code != c.code {
+			t.Errorf("%s: expected: %d got: %d %s", c.name, c.code, w.Code, w.Body)
+		}
+	}
+
+	// csv bodies are limited too
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/tree", strings.NewReader("address\n"+strings.Repeat("0x0000000000000000000000000000000000000001\n", 10)))
+	r.Header.Set("Content-Type", "text/csv")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("csv: expected: %d got: %d %s", http.StatusRequestEntityTooLarge, w.Code, w.Body)
+	}
+
+	var got Limits
+	if code := do(t, h, http.MethodGet, "/api/v1/limits", nil, &got); code != http.StatusOK {
+		t.Fatalf("get limits: expected: %d got: %d", http.StatusOK, code)
+	}
+	if got != s.limits {
+		t.Errorf("expected: %+v got: %+v", s.limits, got)
+	}
+}
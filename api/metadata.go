@@ -0,0 +1,167 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Optional, human readable details of a tree
+// so that it can be recognised in explorers.
+type Metadata struct {
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Creator     *common.Address `json:"creator,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	ChainID     uint64          `json:"chainId,omitempty"`
+	ExternalURL string          `json:"externalUrl,omitempty"`
+}
+
+// Metadata as persisted by a [Store]
+type MetadataRecord struct {
+	Metadata
+
+	// sha256 of the token returned when the
+	// metadata was created. Required to edit it.
+	EditTokenHash []byte
+}
+
+const (
+	maxNameLen        = 100
+	maxDescriptionLen = 1000
+	maxTags           = 10
+	maxTagLen         = 32
+	maxURLLen         = 2048
+)
+
+func (m Metadata) validate() error {
+	switch {
+	case utf8.RuneCountInString(m.Name) > maxNameLen:
+		return &FieldError{"name", fmt.Sprintf("name must be at most %d characters", maxNameLen)}
+	case utf8.RuneCountInString(m.Description) > maxDescriptionLen:
+		return &FieldError{"description", fmt.Sprintf("description must be at most %d characters", maxDescriptionLen)}
+	case len(m.Tags) > maxTags:
+		return &FieldError{"tags", fmt.Sprintf("at most %d tags are allowed", maxTags)}
+	case len(m.ExternalURL) > maxURLLen:
+		return &FieldError{"externalUrl", fmt.Sprintf("externalUrl must be at most %d characters", maxURLLen)}
+	}
+	for i, t := range m.Tags {
+		if t == "" || utf8.RuneCountInString(t) > maxTagLen {
+			return &FieldError{fmt.Sprintf("tags[%d]", i), fmt.Sprintf("tags must be between 1 and %d characters", maxTagLen)}
+		}
+	}
+	if m.ExternalURL != "" {
+		u, err := url.Parse(m.ExternalURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &FieldError{"externalUrl", "externalUrl must be an http or https url"}
+		}
+	}
+	return nil
+}
+
+func creatorBytes(a *common.Address) []byte {
+	if a == nil {
+		return nil
+	}
+	return a.Bytes()
+}
+
+func bytesCreator(b []byte) *common.Address {
+	if len(b) == 0 {
+		return nil
+	}
+	a := common.BytesToAddress(b)
+	return &a
+}
+
+// Tags are stored as an empty list rather than null
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// Returns a random token for editing metadata
+// along with the hash that is stored.
+func newEditToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	t := hexutil.Encode(b)
+	h := sha256.Sum256([]byte(t))
+	return t, h[:], nil
+}
+
+func validEditToken(token string, hash []byte) bool {
+	h := sha256.Sum256([]byte(token))
+	return len(hash) > 0 && subtle.ConstantTimeCompare(h[:], hash) == 1
+}
+
+func (s *Server) MetadataHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		s.UpdateMetadata(w, r)
+		return
+	default:
+		s.sendJSONError(r, w, nil, http.StatusMethodNotAllowed, "unsupported method")
+		return
+	}
+}
+
+// Replaces the metadata of a tree. Requests must
+// include the edit token returned when the tree was
+// created as a bearer token.
+func (s *Server) UpdateMetadata(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		root = r.URL.Query().Get("root")
+		m    Metadata
+	)
+	if root == "" {
+		s.sendFieldError(r, w, nil, "root", "missing root")
+		return
+	}
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		s.sendBodyError(r, w, err, "invalid request body")
+		return
+	}
+	if err := m.validate(); err != nil {
+		s.sendValidationError(r, w, err, "")
+		return
+	}
+
+	rb := common.FromHex(root)
+	rec, err := s.store.GetMetadata(ctx, rb)
+	if errors.Is(err, ErrNotFound) {
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeMetadataNotFound, "metadata not found for root")
+		return
+	} else if err != nil {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting metadata")
+		return
+	}
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") || !validEditToken(strings.TrimPrefix(auth, "Bearer "), rec.EditTokenHash) {
+		s.sendCodedError(r, w, nil, http.StatusForbidden, CodeInvalidEditToken, "invalid edit token")
+		return
+	}
+
+	if err := s.store.UpdateMetadata(ctx, rb, m); err != nil {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "updating metadata")
+		return
+	}
+	s.sendJSON(r, w, m)
+}
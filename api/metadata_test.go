@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/contextwtf/lanyard/merkle"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestValidateMetadata(t *testing.T) {
+	cases := []struct {
+		m   Metadata
+		err bool
+	}{
+		{Metadata{}, false},
+		{Metadata{Name: "Allowlist", Tags: []string{"mint"}, ExternalURL: "https://lanyard.org"}, false},
+		{Metadata{Name: strings.Repeat("a", maxNameLen+1)}, true},
+		{Metadata{Tags: []string{""}}, true},
+		{Metadata{Tags: make([]string, maxTags+1)}, true},
+		{Metadata{ExternalURL: "javascript:alert(1)"}, true},
+		{Metadata{ExternalURL: "lanyard.org"}, true},
+	}
+	for i, c := range cases {
+		if err := c.m.validate(); (err != nil) != c.err {
+			t.Errorf("%d: expected error: %t got: %v", i, c.err, err)
+		}
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	for _, ts := range testStores {
+		t.Run(ts.name, func(t *testing.T) {
+			testMetadata(t, New(ts.open(t)).Handler("test", ""))
+		})
+	}
+}
+
+// Creates a tree with metadata and edits it through h.
+func testMetadata(t *testing.T, h http.Handler) {
+	var (
+		creator = common.HexToAddress("0x0000000000000000000000000000000000000001")
+		req     = createTreeReq{
+			Leaves: []string{
+				"0x0000000000000000000000000000000000000004",
+				"0x0000000000000000000000000000000000000005",
+			},
+			Metadata: &Metadata{
+				Name:    "Allowlist",
+				Creator: &creator,
+				Tags:    []string{"mint"},
+				ChainID: 1,
+			},
+		}
+		created createTreeResp
+	)
+	code := do(t, h, http.MethodPost, "/api/v1/tree", req, &created)
+	if code != http.StatusOK {
+		t.Fatalf("create: expected: %d got: %d", http.StatusOK, code)
+	}
+	if created.EditToken == "" {
+		t.Fatal("expected an edit token")
+	}
+
+	var again createTreeResp
+	do(t, h, http.MethodPost, "/api/v1/tree", req, &again)
+	if again.EditToken != "" {
+		t.Error("expected no edit token when metadata exists")
+	}
+
+	var tree getTreeResp
+	do(t, h, http.MethodGet, "/api/v1/tree?root="+created.MerkleRoot, nil, &tree)
+	if tree.Metadata == nil || tree.Metadata.Name != "Allowlist" || *tree.Metadata.Creator != creator || tree.Metadata.ChainID != 1 {
+		t.Fatalf("unexpected metadata: %+v", tree.Metadata)
+	}
+
+	update := func(token string, m Metadata) int {
+		b, _ := json.Marshal(m)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/api/v1/tree/metadata?root="+created.MerkleRoot, bytes.NewReader(b))
+		r.Header.Set("Authorization", "Bearer "+token)
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	cases := []struct {
+		token string
+		m     Metadata
+		code  int
+	}{
+		{"0x00", Metadata{Name: "Stolen"}, http.StatusForbidden},
+		{created.EditToken, Metadata{Name: strings.Repeat("a", maxNameLen+1)}, http.StatusBadRequest},
+		{created.EditToken, Metadata{Name: "Renamed", Tags: []string{"a", "b"}}, http.StatusOK},
+	}
+	for i, c := range cases {
+		if code := update(c.token, c.m); code != c.code {
+			t.Errorf("update %d: expected: %d got: %d", i, c.code, code)
+		}
+	}
+
+	tree = getTreeResp{}
+	do(t, h, http.MethodGet, "/api/v1/tree?root="+created.MerkleRoot, nil, &tree)
+	if tree.Metadata == nil || tree.Metadata.Name != "Renamed" || len(tree.Metadata.Tags) != 2 || tree.Metadata.Creator != nil {
+		t.Errorf("unexpected metadata: %+v", tree.Metadata)
+	}
+}
+
+func TestMetadataExistingTree(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		h        = New(NewMemStore()).Handler("test", "")
+		unsigned = []string{"0x01", "0x02"}
+		signed   = []string{"0x03", "0x04"}
+		root     = merkle.New([][]byte{common.FromHex(signed[0]), common.FromHex(signed[1])}).Root()
+		md       = &Metadata{Name: "Phishing"}
+	)
+	created := createTree(t, h, createTreeReq{Leaves: unsigned})
+	again := createTree(t, h, createTreeReq{Leaves: unsigned, Metadata: md})
+	if again.EditToken != "" {
+		t.Error("expected no edit token for an existing tree")
+	}
+	var tree getTreeResp
+	do(t, h, http.MethodGet, "/api/v1/tree?root="+created.MerkleRoot, nil, &tree)
+	if tree.Metadata != nil {
+		t.Errorf("expected no metadata got: %+v", tree.Metadata)
+	}
+
+	// the owner can attach metadata later
+	createTree(t, h, createTreeReq{Leaves: signed, Signature: personalSign(t, key, root)})
+	owned := createTree(t, h, createTreeReq{Leaves: signed, Signature: personalSign(t, key, root), Metadata: md})
+	if owned.EditToken == "" {
+		t.Error("expected an edit token for the owner")
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/contextwtf/lanyard/api/tracing"
+	"github.com/contextwtf/lanyard/merkle"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus metrics for a [Server]. Each server has
+// its own registry so that tests can create many servers.
+type metrics struct {
+	reg *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+
+	cacheHits      prometheus.Counter
+	cacheMisses    prometheus.Counter
+	cacheEvictions prometheus.Counter
+
+	treeBuildDuration prometheus.Histogram
+	treeLeaves        prometheus.Histogram
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		reg: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "lanyard_http_requests_total",
+			Help: "HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "lanyard_http_request_duration_seconds",
+			Help:    "HTTP request latency by route, method and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		cacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "lanyard_tree_cache_hits_total",
+			Help: "Trees found in the tree cache.",
+		}),
+		cacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "lanyard_tree_cache_misses_total",
+			Help: "Trees loaded from the store into the tree cache.",
+		}),
+		cacheEvictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "lanyard_tree_cache_evictions_total",
+			Help: "Trees evicted or removed from the tree cache.",
+		}),
+		treeBuildDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "lanyard_tree_build_duration_seconds",
+			Help:    "Time taken to build a merkle tree from its leaves.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}),
+		treeLeaves: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "lanyard_tree_leaves",
+			Help:    "Number of leaves in each tree that is built.",
+			Buckets: prometheus.ExponentialBuckets(2, 4, 10),
+		}),
+	}
+	m.reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.cacheHits,
+		m.cacheMisses,
+		m.cacheEvictions,
+		m.treeBuildDuration,
+		m.treeLeaves,
+	)
+	return m
+}
+
+// Registers the size of the tree cache and, if the
+// store exports metrics such as [PGStore], the store.
+func (s *Server) registerMetrics() {
+	s.metrics.reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "lanyard_tree_cache_size",
+		Help: "Trees in the tree cache.",
+	}, func() float64 {
+		return float64(s.tlru.Len())
+	}))
+	if c, ok := s.store.(prometheus.Collector); ok {
+		s.metrics.reg.MustRegister(c)
+	}
+}
+
+func (s *Server) buildTree(ctx context.Context, leaves [][]byte) merkle.Tree {
+	span, _ := tracing.StartSpan(ctx, "merkle.build", tracing.Tag("tree.leaves", len(leaves)))
+	defer span.Finish()
+
+	start := time.Now()
+	t := merkle.New(leaves)
+	s.metrics.treeBuildDuration.Observe(time.Since(start).Seconds())
+	s.metrics.treeLeaves.Observe(float64(len(leaves)))
+	return t
+}
+
+// Records the count and latency of requests. Requests
+// are labeled with the pattern of the route they matched
+// so that roots and addresses don't create new series.
+func (s *Server) metricsHandler(mux *http.ServeMux, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		sc := &statusCapture{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		h.ServeHTTP(sc, r)
+
+		var (
+			status = strconv.Itoa(sc.status)
+			method = r.Method
+		)
+		if !knownMethods[method] {
+			method = "other"
+		}
+		s.metrics.requests.WithLabelValues(route, method, status).Inc()
+		s.metrics.requestDuration.WithLabelValues(route, method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// Clients can send any method so the rest are
+// labeled as other to bound the number of series.
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// Serves the server's Prometheus metrics. Metrics are
+// not part of [Server.Handler] so that they can be served
+// on an address that isn't public.
+func (s *Server) MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(s.metrics.reg, promhttp.HandlerOpts{})
+}
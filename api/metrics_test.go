@@ -0,0 +1,57 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	var (
+		s      = New(NewMemStore())
+		h      = s.Handler("test", "")
+		leaves = []string{
+			"0x0000000000000000000000000000000000000001",
+			"0x0000000000000000000000000000000000000002",
+		}
+		created createTreeResp
+	)
+	do(t, h, http.MethodPost, "/api/v1/tree", createTreeReq{Leaves: leaves}, &created)
+	for i := 0; i < 2; i++ {
+		do(t, h, http.MethodGet, "/api/v1/proof?root="+created.MerkleRoot+"&unhashedLeaf="+leaves[0], nil, nil)
+	}
+	do(t, h, http.MethodGet, "/api/v1/proof?root="+created.MerkleRoot+"&unhashedLeaf=0x03", nil, nil)
+	do(t, h, "BREW", "/api/v1/proof", nil, nil)
+
+	// metrics are only served by MetricsHandler
+	if code := do(t, h, http.MethodGet, "/metrics", nil, nil); code != http.StatusNotFound {
+		t.Errorf("expected: %d got: %d", http.StatusNotFound, code)
+	}
+	w := httptest.NewRecorder()
+	s.MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected: %d got: %d", http.StatusOK, w.Code)
+	}
+	b, err := io.ReadAll(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`lanyard_http_requests_total{method="POST",route="/api/v1/tree",status="200"} 1`,
+		`lanyard_http_requests_total{method="GET",route="/api/v1/proof",status="200"} 2`,
+		`lanyard_http_requests_total{method="GET",route="/api/v1/proof",status="404"} 1`,
+		`lanyard_http_requests_total{method="other",route="/api/v1/proof",status="400"} 1`,
+		`lanyard_tree_cache_hits_total 2`,
+		`lanyard_tree_cache_misses_total 1`,
+		`lanyard_tree_cache_size 1`,
+		// built once when created and once when cached
+		`lanyard_tree_leaves_count 2`,
+		`lanyard_tree_build_duration_seconds_count 2`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("expected metrics to contain: %s", want)
+		}
+	}
+}
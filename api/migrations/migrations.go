@@ -133,4 +133,116 @@ var Migrations = []migrate.Migration{
 		DROP TABLE "trees_proofs";
 		`,
 	},
+	{
+		Name: "2026-10-19.0.typed-data.sql",
+		SQL: `
+		CREATE TABLE IF NOT EXISTS typed_data (
+			root bytea PRIMARY KEY,
+			types jsonb NOT NULL,
+			primary_type text NOT NULL,
+			messages jsonb NOT NULL,
+			domain jsonb NOT NULL
+		);
+		`,
+	},
+	{
+		Name: "2026-10-19.1.salted.sql",
+		SQL: `
+		ALTER TABLE trees
+		ADD COLUMN salted boolean NOT NULL DEFAULT false;
+		`,
+	},
+	{
+		Name: "2026-10-19.2.tree-leaves.sql",
+		SQL: `
+		CREATE TABLE IF NOT EXISTS tree_leaves (
+			root bytea NOT NULL,
+			idx integer NOT NULL,
+			leaf bytea NOT NULL,
+			PRIMARY KEY (root, idx)
+		);
+		INSERT INTO tree_leaves (root, idx, leaf)
+		SELECT t.root, l.idx - 1, l.leaf
+		FROM trees t, unnest(t.unhashed_leaves) WITH ORDINALITY AS l(leaf, idx);
+
+		ALTER TABLE trees
+		ADD COLUMN leaf_count integer;
+
+		UPDATE trees SET leaf_count = cardinality(unhashed_leaves);
+
+		ALTER TABLE trees
+		ALTER COLUMN leaf_count
+		SET NOT NULL;
+		`,
+	},
+	{
+		Name: "2026-10-19.3.tree-metadata.sql",
+		SQL: `
+		CREATE TABLE IF NOT EXISTS tree_metadata (
+			root bytea PRIMARY KEY,
+			name text NOT NULL DEFAULT '',
+			description text NOT NULL DEFAULT '',
+			creator bytea,
+			tags text[] NOT NULL DEFAULT '{}',
+			chain_id bigint NOT NULL DEFAULT 0,
+			external_url text NOT NULL DEFAULT '',
+			edit_token_hash bytea,
+			inserted_at timestamptz NOT NULL DEFAULT now(),
+			updated_at timestamptz NOT NULL DEFAULT now()
+		);
+		CREATE INDEX IF NOT EXISTS tree_metadata_creator_idx ON tree_metadata (creator);
+		CREATE INDEX IF NOT EXISTS tree_metadata_tags_idx ON tree_metadata USING gin (tags);
+		`,
+	},
+	{
+		Name: "2026-10-19.4.tree-owners.sql",
+		SQL: `
+		CREATE TABLE IF NOT EXISTS tree_owners (
+			root bytea PRIMARY KEY,
+			owner bytea NOT NULL,
+			hidden boolean NOT NULL DEFAULT false,
+			signed_at bigint NOT NULL DEFAULT 0,
+			inserted_at timestamptz NOT NULL DEFAULT now()
+		);
+		CREATE INDEX IF NOT EXISTS tree_owners_owner_idx ON tree_owners (owner);
+		CREATE INDEX IF NOT EXISTS proofs_hashes_root_idx ON proofs_hashes (root);
+		`,
+	},
+	{
+		Name: "2026-10-19.5.tree-expiry.sql",
+		SQL: `
+		ALTER TABLE trees
+		ADD COLUMN IF NOT EXISTS inserted_at timestamptz NOT NULL DEFAULT now(),
+		ADD COLUMN IF NOT EXISTS expires_at timestamptz,
+		ADD COLUMN IF NOT EXISTS accessed_at timestamptz;
+
+		-- existing trees count as read so they aren't pruned
+		UPDATE trees SET accessed_at = now() WHERE accessed_at IS NULL;
+
+		CREATE INDEX IF NOT EXISTS trees_expires_at_idx
+		ON trees (expires_at)
+		WHERE expires_at IS NOT NULL;
+
+		CREATE INDEX IF NOT EXISTS trees_unaccessed_idx
+		ON trees (inserted_at)
+		WHERE accessed_at IS NULL;
+		`,
+	},
+	{
+		Name: "2026-10-19.6.rate-limits.sql",
+		SQL: `
+		CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+			key text PRIMARY KEY,
+			tokens double precision NOT NULL,
+			updated_at timestamptz NOT NULL
+		);
+		`,
+	},
+	{
+		Name: "2026-10-19.7.drop-unhashed-leaves.sql",
+		SQL: `
+		ALTER TABLE trees
+		DROP COLUMN IF EXISTS unhashed_leaves;
+		`,
+	},
 }
@@ -81,9 +81,10 @@ func main() {
 
 	log.Println("fetching roots from db")
 	const q = `
-		SELECT unhashed_leaves
-		FROM trees
+		SELECT array_agg(leaf ORDER BY idx)
+		FROM tree_leaves
 		WHERE root not in (select root from proofs_hashes group by 1)
+		GROUP BY root
 	`
 	rows, err := db.Query(ctx, q)
 	check(err)
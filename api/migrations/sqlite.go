@@ -0,0 +1,192 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/contextwtf/migrate"
+)
+
+// SQLite is the SQLite equivalent of [Migrations].
+// The tables match the Postgres schema except that
+// arrays and jsonb are stored as JSON text.
+// Since SQLite databases are new there is no history
+// to replay and the list starts at the current schema.
+// Times other than inserted_at are stored as unix seconds.
+var SQLite = []migrate.Migration{
+	{
+		Name: "2026-10-19.0.init.sql",
+		SQL: `
+		CREATE TABLE trees (
+			root blob PRIMARY KEY,
+			unhashed_leaves text NOT NULL,
+			ltd text,
+			packed boolean NOT NULL,
+			salted boolean NOT NULL DEFAULT false,
+			inserted_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE proofs_hashes (
+			hash blob,
+			root blob
+		);
+		CREATE INDEX proofs_hashes_hash_idx ON proofs_hashes (hash);
+		CREATE TABLE typed_data (
+			root blob PRIMARY KEY,
+			types text NOT NULL,
+			primary_type text NOT NULL,
+			messages text NOT NULL,
+			domain text NOT NULL
+		);
+		`,
+	},
+	{
+		Name: "2026-10-19.1.tree-leaves.sql",
+		SQL: `
+		CREATE TABLE tree_leaves (
+			root blob NOT NULL,
+			idx integer NOT NULL,
+			leaf blob NOT NULL,
+			PRIMARY KEY (root, idx)
+		);
+		INSERT INTO tree_leaves (root, idx, leaf)
+		SELECT t.root, l.key, unhex(substr(l.value, 3))
+		FROM trees t, json_each(t.unhashed_leaves) l;
+
+		ALTER TABLE trees
+		ADD COLUMN leaf_count integer NOT NULL DEFAULT 0;
+
+		UPDATE trees SET leaf_count = json_array_length(unhashed_leaves);
+		`,
+	},
+	{
+		Name: "2026-10-19.2.tree-metadata.sql",
+		SQL: `
+		CREATE TABLE tree_metadata (
+			root blob PRIMARY KEY,
+			name text NOT NULL DEFAULT '',
+			description text NOT NULL DEFAULT '',
+			creator blob,
+			tags text NOT NULL DEFAULT '[]',
+			chain_id integer NOT NULL DEFAULT 0,
+			external_url text NOT NULL DEFAULT '',
+			edit_token_hash blob,
+			inserted_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX tree_metadata_creator_idx ON tree_metadata (creator);
+		`,
+	},
+	{
+		Name: "2026-10-19.3.tree-owners.sql",
+		SQL: `
+		CREATE TABLE tree_owners (
+			root blob PRIMARY KEY,
+			owner blob NOT NULL,
+			hidden boolean NOT NULL DEFAULT false,
+			signed_at integer NOT NULL DEFAULT 0,
+			inserted_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX tree_owners_owner_idx ON tree_owners (owner);
+		CREATE INDEX proofs_hashes_root_idx ON proofs_hashes (root);
+		`,
+	},
+	{
+		Name: "2026-10-19.4.tree-expiry.sql",
+		SQL: `
+		ALTER TABLE trees ADD COLUMN expires_at integer;
+		ALTER TABLE trees ADD COLUMN accessed_at integer;
+		-- existing trees count as read so they aren't pruned
+		UPDATE trees SET accessed_at = unixepoch();
+		CREATE INDEX trees_expires_at_idx
+		ON trees (expires_at)
+		WHERE expires_at IS NOT NULL;
+		CREATE INDEX trees_unaccessed_idx
+		ON trees (inserted_at)
+		WHERE accessed_at IS NULL;
+		`,
+	},
+	{
+		Name: "2026-10-19.5.drop-unhashed-leaves.sql",
+		SQL: `
+		ALTER TABLE trees DROP COLUMN unhashed_leaves;
+		`,
+	},
+}
+
+func hash(m migrate.Migration) string {
+	// same as migrate so hashes are
+	// comparable across databases
+	h := sha256.Sum256([]byte(strings.Join(strings.Fields(m.SQL), " ")))
+	return hex.EncodeToString(h[:])
+}
+
+// RunSQLite applies the migrations in ms that have not been
+// applied to db. The migrate package relies on Postgres
+// features so SQLite migrations are tracked here using a
+// migrations table with the same columns.
+// Applied migrations must be a prefix of ms and their hash
+// must not have changed.
+func RunSQLite(ctx context.Context, db *sql.DB, ms []migrate.Migration) error {
+	const q = `
+		CREATE TABLE IF NOT EXISTS migrations (
+			filename text NOT NULL PRIMARY KEY,
+			hash text NOT NULL,
+			applied_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			"index" integer NOT NULL
+		);
+	`
+	if _, err := db.ExecContext(ctx, q); err != nil {
+		return fmt.Errorf("creating migration table: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT filename, hash FROM migrations ORDER BY "index"`)
+	if err != nil {
+		return fmt.Errorf("selecting migrations: %w", err)
+	}
+	var applied []migrate.Migration
+	for rows.Next() {
+		var m migrate.Migration
+		if err := rows.Scan(&m.Name, &m.Hash); err != nil {
+			rows.Close()
+			return fmt.Errorf("scanning migration: %w", err)
+		}
+		applied = append(applied, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("selecting migrations: %w", err)
+	}
+
+	if len(applied) > len(ms) {
+		return fmt.Errorf("%d migrations applied but only %d known", len(applied), len(ms))
+	}
+	for i, a := range applied {
+		if a.Name != ms[i].Name || a.Hash != hash(ms[i]) {
+			return fmt.Errorf("migration %s at %d does not match %s", a.Name, i, ms[i].Name)
+		}
+	}
+
+	for i, m := range ms[len(applied):] {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin tx: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("applying %s: %w", m.Name, err)
+		}
+		const iq = `INSERT INTO migrations (filename, hash, "index") VALUES (?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, iq, m.Name, hash(m), len(applied)+i+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("recording %s: %w", m.Name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("committing %s: %w", m.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The owner of a tree as persisted by a [Store]
+type OwnerRecord struct {
+	Owner common.Address
+
+	// Hidden trees are not served by any endpoint
+	// or returned when looking up roots by proof
+	Hidden bool
+
+	// Timestamp of the last signed request. Requests must
+	// have a later timestamp so they cannot be replayed.
+	SignedAt int64
+}
+
+// Returns the address that signed msg using
+// personal_sign (EIP-191 version 0x45).
+func recoverSigner(msg, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.New("signature must be 65 bytes")
+	}
+	// wallets produce a recovery id of 27 or 28
+	s := make([]byte, len(sig))
+	copy(s, sig)
+	if s[crypto.RecoveryIDOffset] >= 27 {
+		s[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(msg), s)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid signature: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+const (
+	ownerActionUpdateMetadata = "updateMetadata"
+	ownerActionHide           = "hide"
+	ownerActionUnhide         = "unhide"
+	ownerActionDelete         = "delete"
+
+	// Signed messages older than this are rejected
+	maxSignatureAge = 10 * time.Minute
+)
+
+// The JSON message signed by the owner of a tree.
+// Signing the whole message means the server only acts
+// on exactly what the owner saw in their wallet.
+type ownerMessage struct {
+	Action    string        `json:"action"`
+	Root      hexutil.Bytes `json:"root"`
+	Timestamp int64         `json:"timestamp"`
+	Metadata  *Metadata     `json:"metadata,omitempty"`
+}
+
+type ownerReq struct {
+	// The JSON encoded [ownerMessage] as it was signed
+	Message   string        `json:"message"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+type ownerResp struct {
+	Root    hexutil.Bytes  `json:"root"`
+	Owner   common.Address `json:"owner"`
+	Hidden  bool           `json:"hidden"`
+	Deleted bool           `json:"deleted,omitempty"`
+}
+
+// Performs an action on a tree that was requested by its
+// owner. The owner is the address that signed the root
+// when the tree was created.
+func (s *Server) OwnerAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendJSONError(r, w, nil, http.StatusMethodNotAllowed, "unsupported method")
+		return
+	}
+
+	var (
+		req ownerReq
+		msg ownerMessage
+		ctx = r.Context()
+	)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendBodyError(r, w, err, "invalid request body")
+		return
+	}
+	if err := json.Unmarshal([]byte(req.Message), &msg); err != nil {
+		s.sendJSONError(r, w, err, http.StatusBadRequest, "invalid message")
+		return
+	}
+	signer, err := recoverSigner([]byte(req.Message), req.Signature)
+	if err != nil {
+		s.sendJSONError(r, w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if age := time.Since(time.Unix(msg.Timestamp, 0)); age > maxSignatureAge || age < -maxSignatureAge {
+		s.sendCodedError(r, w, nil, http.StatusBadRequest, CodeSignatureExpired, "message timestamp must be within 10 minutes")
+		return
+	}
+	switch msg.Action {
+	case ownerActionUpdateMetadata:
+		if msg.Metadata == nil {
+			s.sendFieldError(r, w, nil, "metadata", "missing metadata")
+			return
+		}
+		if err := msg.Metadata.validate(); err != nil {
+			s.sendValidationError(r, w, err, "metadata.")
+			return
+		}
+	case ownerActionHide, ownerActionUnhide, ownerActionDelete:
+	default:
+		s.sendJSONError(r, w, nil, http.StatusBadRequest, "unknown action")
+		return
+	}
+
+	own, err := s.store.GetOwner(ctx, msg.Root)
+	if errors.Is(err, ErrNotFound) {
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeOwnerNotFound, "tree has no owner")
+		return
+	} else if err != nil {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting owner")
+		return
+	}
+	if signer != own.Owner {
+		s.sendCodedError(r, w, nil, http.StatusForbidden, CodeNotOwner, "message must be signed by the owner")
+		return
+	}
+
+	hidden := own.Hidden
+	switch msg.Action {
+	case ownerActionHide:
+		hidden = true
+	case ownerActionUnhide:
+		hidden = false
+	}
+	// claims the timestamp so that the message can't be replayed
+	ok, err := s.store.UpdateOwner(ctx, msg.Root, hidden, msg.Timestamp)
+	if err != nil {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "updating owner")
+		return
+	} else if !ok {
+		s.sendCodedError(r, w, nil, http.StatusConflict, CodeSignatureReplay, "message timestamp must be after the last signed message")
+		return
+	}
+
+	resp := ownerResp{Root: msg.Root, Owner: own.Owner, Hidden: hidden}
+	switch msg.Action {
+	case ownerActionHide:
+		s.purgeTree(ctx, msg.Root)
+	case ownerActionUpdateMetadata:
+		err = s.store.UpdateMetadata(ctx, msg.Root, *msg.Metadata)
+		if errors.Is(err, ErrNotFound) {
+			_, err = s.store.InsertMetadata(ctx, msg.Root, MetadataRecord{Metadata: *msg.Metadata})
+		}
+		if err != nil {
+			s.sendJSONError(r, w, err, http.StatusInternalServerError, "updating metadata")
+			return
+		}
+	case ownerActionDelete:
+		if err := s.store.DeleteTree(ctx, msg.Root); err != nil {
+			s.sendJSONError(r, w, err, http.StatusInternalServerError, "deleting tree")
+			return
+		}
+		s.tlru.Remove(common.BytesToHash(msg.Root))
+		s.purgeTree(ctx, msg.Root)
+		resp.Deleted = true
+	}
+	s.sendJSON(r, w, resp)
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/contextwtf/lanyard/merkle"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signs msg like a wallet's personal_sign
+func personalSign(t *testing.T, key *ecdsa.PrivateKey, msg []byte) hexutil.Bytes {
+	t.Helper()
+	sig, err := crypto.Sign(accounts.TextHash(msg), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig
+}
+
+func TestRecoverSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		msg  = []byte("lanyard")
+		want = crypto.PubkeyToAddress(key.PublicKey)
+		sig  = personalSign(t, key, msg)
+	)
+	got, err := recoverSigner(msg, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("expected: %s got: %s", want, got)
+	}
+	if got, _ := recoverSigner([]byte("other"), sig); got == want {
+		t.Error("expected a different signer for a different message")
+	}
+	if _, err := recoverSigner(msg, sig[:64]); err == nil {
+		t.Error("expected error for short signature")
+	}
+}
+
+func TestOwner(t *testing.T) {
+	for _, ts := range testStores {
+		t.Run(ts.name, func(t *testing.T) {
+			var (
+				s      = New(ts.open(t))
+				purged int
+			)
+			s.SetPurge(func(context.Context, []byte) error {
+				purged++
+				return nil
+			})
+			testOwner(t, s.Handler("test", ""))
+			if purged != 2 {
+				t.Errorf("expected the tree to be purged when hidden and deleted got: %d", purged)
+			}
+		})
+	}
+}
+
+// Creates a signed tree and acts on it as its owner through h.
+func testOwner(t *testing.T, h http.Handler) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	thief, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		owner    = crypto.PubkeyToAddress(key.PublicKey)
+		unsigned = []string{
+			"0x0000000000000000000000000000000000000006",
+			"0x0000000000000000000000000000000000000007",
+		}
+		leaves = []string{
+			"0x0000000000000000000000000000000000000008",
+			"0x0000000000000000000000000000000000000009",
+		}
+		root = merkle.New([][]byte{common.FromHex(leaves[0]), common.FromHex(leaves[1])}).Root()
+	)
+
+	// trees created without a signature can't be claimed later
+	var created createTreeResp
+	do(t, h, http.MethodPost, "/api/v1/tree", createTreeReq{Leaves: unsigned}, &created)
+	code := do(t, h, http.MethodPost, "/api/v1/tree", createTreeReq{
+		Leaves:    unsigned,
+		Signature: personalSign(t, key, common.FromHex(created.MerkleRoot)),
+	}, nil)
+	if code != http.StatusConflict {
+		t.Errorf("claim unsigned: expected: %d got: %d", http.StatusConflict, code)
+	}
+
+	for i := 0; i < 2; i++ {
+		created = createTreeResp{}
+		code = do(t, h, http.MethodPost, "/api/v1/tree", createTreeReq{
+			Leaves:    leaves,
+			Signature: personalSign(t, key, root),
+		}, &created)
+		if code != http.StatusOK {
+			t.Fatalf("create signed %d: expected: %d got: %d", i, http.StatusOK, code)
+		}
+		if created.Owner == nil || *created.Owner != owner {
+			t.Errorf("create signed %d: expected owner: %s got: %v", i, owner, created.Owner)
+		}
+	}
+	code = do(t, h, http.MethodPost, "/api/v1/tree", createTreeReq{
+		Leaves:    leaves,
+		Signature: personalSign(t, thief, root),
+	}, nil)
+	if code != http.StatusConflict {
+		t.Errorf("create with other signer: expected: %d got: %d", http.StatusConflict, code)
+	}
+
+	var proof getProofResp
+	do(t, h, http.MethodGet, "/api/v1/proof?root="+created.MerkleRoot+"&unhashedLeaf="+leaves[0], nil, &proof)
+	var ps []string
+	for _, p := range proof.Proof {
+		ps = append(ps, p.String())
+	}
+	rootsPath := "/api/v1/roots?proof=" + strings.Join(ps, ",")
+
+	var (
+		now = time.Now().Unix()
+		act = func(key *ecdsa.PrivateKey, m ownerMessage) int {
+			b, err := json.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return do(t, h, http.MethodPost, "/api/v1/tree/owner", ownerReq{
+				Message:   string(b),
+				Signature: personalSign(t, key, b),
+			}, nil)
+		}
+		hide = ownerMessage{Action: ownerActionHide, Root: root, Timestamp: now}
+	)
+	cases := []struct {
+		desc  string
+		key   *ecdsa.PrivateKey
+		m     ownerMessage
+		code  int
+		found int // for the tree and its roots
+	}{
+		{"not owner", thief, hide, http.StatusForbidden, http.StatusOK},
+		{"hide", key, hide, http.StatusOK, http.StatusNotFound},
+		{"replay", key, hide, http.StatusConflict, http.StatusNotFound},
+		{"expired", key, ownerMessage{Action: ownerActionUnhide, Root: root, Timestamp: now - 3600}, http.StatusBadRequest, http.StatusNotFound},
+		{"unknown", key, ownerMessage{Action: "transfer", Root: root, Timestamp: now + 1}, http.StatusBadRequest, http.StatusNotFound},
+		{"unhide", key, ownerMessage{Action: ownerActionUnhide, Root: root, Timestamp: now + 1}, http.StatusOK, http.StatusOK},
+		{"metadata", key, ownerMessage{Action: ownerActionUpdateMetadata, Root: root, Timestamp: now + 2, Metadata: &Metadata{Name: "Owned"}}, http.StatusOK, http.StatusOK},
+	}
+	for _, c := range cases {
+		if code := act(c.key, c.m); code != c.code {
+			t.Errorf("%s: expected: %d got: %d", c.desc, c.code, code)
+		}
+		if code := do(t, h, http.MethodGet, rootsPath, nil, nil); code != c.found {
+			t.Errorf("%s roots: expected: %d got: %d", c.desc, c.found, code)
+		}
+		for _, path := range []string{
+			"/api/v1/tree?root=" + created.MerkleRoot,
+			"/api/v1/proof?root=" + created.MerkleRoot + "&unhashedLeaf=" + leaves[0],
+			"/api/v1/tree/proofs?root=" + created.MerkleRoot,
+			"/api/v1/tree/export?format=merkletreejs&root=" + created.MerkleRoot,
+		} {
+			if code := do(t, h, http.MethodGet, path, nil, nil); code != c.found {
+				t.Errorf("%s %s: expected: %d got: %d", c.desc, path, c.found, code)
+			}
+		}
+	}
+
+	var tree getTreeResp
+	do(t, h, http.MethodGet, "/api/v1/tree?root="+created.MerkleRoot, nil, &tree)
+	if tree.Owner == nil || *tree.Owner != owner {
+		t.Errorf("expected tree owned by %s got: %v", owner, tree.Owner)
+	}
+	if tree.Metadata == nil || tree.Metadata.Name != "Owned" {
+		t.Errorf("unexpected metadata: %+v", tree.Metadata)
+	}
+
+	code = act(key, ownerMessage{Action: ownerActionDelete, Root: root, Timestamp: now + 3})
+	if code != http.StatusOK {
+		t.Fatalf("delete: expected: %d got: %d", http.StatusOK, code)
+	}
+	for _, path := range []string{
+		"/api/v1/tree?root=" + created.MerkleRoot,
+		"/api/v1/proof?root=" + created.MerkleRoot + "&unhashedLeaf=" + leaves[0],
+		rootsPath,
+	} {
+		if code := do(t, h, http.MethodGet, path, nil, nil); code != http.StatusNotFound {
+			t.Errorf("%s after delete: expected: %d got: %d", path, http.StatusNotFound, code)
+		}
+	}
+}
+
+// Trees cached by one server are hidden and deleted
+// when their owner acts through another.
+func TestOwnerOtherServer(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		store   = NewMemStore()
+		cached  = New(store).Handler("test", "")
+		other   = New(store).Handler("test", "")
+		leaves  = []string{"0x01", "0x02"}
+		root    = merkle.New([][]byte{common.FromHex(leaves[0]), common.FromHex(leaves[1])}).Root()
+		created = createTree(t, other, createTreeReq{Leaves: leaves, Signature: personalSign(t, key, root)})
+		path    = "/api/v1/proof?root=" + created.MerkleRoot + "&unhashedLeaf=" + leaves[0]
+		now     = time.Now().Unix()
+	)
+	cases := []struct {
+		action string
+		found  int
+	}{
+		{ownerActionHide, http.StatusNotFound},
+		{ownerActionUnhide, http.StatusOK},
+		{ownerActionDelete, http.StatusNotFound},
+	}
+	for i, c := range cases {
+		if code := do(t, cached, http.MethodGet, path, nil, nil); code != http.StatusOK && i == 0 {
+			t.Fatalf("expected: %d got: %d", http.StatusOK, code)
+		}
+		b, err := json.Marshal(ownerMessage{Action: c.action, Root: root, Timestamp: now + int64(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := do(t, other, http.MethodPost, "/api/v1/tree/owner", ownerReq{Message: string(b), Signature: personalSign(t, key, b)}, nil)
+		if code != http.StatusOK {
+			t.Fatalf("%s: expected: %d got: %d", c.action, http.StatusOK, code)
+		}
+		if code := do(t, cached, http.MethodGet, path, nil, nil); code != c.found {
+			t.Errorf("%s: expected: %d got: %d", c.action, c.found, code)
+		}
+		if c.action != ownerActionHide {
+			continue
+		}
+		w := httptest.NewRecorder()
+		cached.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tree?root="+created.MerkleRoot, nil))
+		if cc := w.Result().Header.Get("Cache-Control"); w.Code != http.StatusNotFound || cc != "public, max-age=60" {
+			t.Errorf("hidden tree: expected: %d with max-age=60 got: %d %q", http.StatusNotFound, w.Code, cc)
+		}
+	}
+}
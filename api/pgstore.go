@@ -0,0 +1,513 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PGStore is a [Store] backed by Postgres.
+// See schema.sql and the migrations package
+// for the tables it uses.
+type PGStore struct {
+	db *pgxpool.Pool
+}
+
+func NewPGStore(db *pgxpool.Pool) *PGStore {
+	return &PGStore{db: db}
+}
+
+var (
+	pgAcquiredConns = prometheus.NewDesc(
+		"lanyard_pgxpool_acquired_conns",
+		"Connections currently acquired from the pool.", nil, nil,
+	)
+	pgIdleConns = prometheus.NewDesc(
+		"lanyard_pgxpool_idle_conns",
+		"Idle connections in the pool.", nil, nil,
+	)
+	pgTotalConns = prometheus.NewDesc(
+		"lanyard_pgxpool_total_conns",
+		"Connections in the pool.", nil, nil,
+	)
+	pgMaxConns = prometheus.NewDesc(
+		"lanyard_pgxpool_max_conns",
+		"Maximum size of the pool.", nil, nil,
+	)
+	pgAcquires = prometheus.NewDesc(
+		"lanyard_pgxpool_acquires_total",
+		"Connections acquired from the pool.", nil, nil,
+	)
+	pgEmptyAcquires = prometheus.NewDesc(
+		"lanyard_pgxpool_empty_acquires_total",
+		"Acquires that waited for a connection because the pool was empty.", nil, nil,
+	)
+	pgCanceledAcquires = prometheus.NewDesc(
+		"lanyard_pgxpool_canceled_acquires_total",
+		"Acquires canceled by their context.", nil, nil,
+	)
+	pgAcquireDuration = prometheus.NewDesc(
+		"lanyard_pgxpool_acquire_duration_seconds_total",
+		"Time spent acquiring connections.", nil, nil,
+	)
+)
+
+// Describe and Collect export the pool's
+// stats as a prometheus.Collector
+func (p *PGStore) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pgAcquiredConns
+	ch <- pgIdleConns
+	ch <- pgTotalConns
+	ch <- pgMaxConns
+	ch <- pgAcquires
+	ch <- pgEmptyAcquires
+	ch <- pgCanceledAcquires
+	ch <- pgAcquireDuration
+}
+
+func (p *PGStore) Collect(ch chan<- prometheus.Metric) {
+	st := p.db.Stat()
+	ch <- prometheus.MustNewConstMetric(pgAcquiredConns, prometheus.GaugeValue, float64(st.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(pgIdleConns, prometheus.GaugeValue, float64(st.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(pgTotalConns, prometheus.GaugeValue, float64(st.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(pgMaxConns, prometheus.GaugeValue, float64(st.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(pgAcquires, prometheus.CounterValue, float64(st.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(pgEmptyAcquires, prometheus.CounterValue, float64(st.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(pgCanceledAcquires, prometheus.CounterValue, float64(st.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(pgAcquireDuration, prometheus.CounterValue, st.AcquireDuration().Seconds())
+}
+
+func (p *PGStore) TreeExists(ctx context.Context, root []byte) (bool, error) {
+	const q = `
+	select exists(
+		select 1 from trees where root = $1
+	)
+	`
+	var exists bool
+	err := p.db.QueryRow(ctx, q, root).Scan(&exists)
+	return exists, err
+}
+
+func (p *PGStore) InsertTree(ctx context.Context, t TreeRecord, proofHashes [][]byte) error {
+	const q = `
+		INSERT INTO trees(
+			root,
+			ltd,
+			packed,
+			salted,
+			leaf_count,
+			expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (root)
+		DO NOTHING
+	`
+
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, q,
+		t.Root,
+		t.Ltd,
+		t.Packed,
+		t.Salted,
+		len(t.Leaves),
+		nullTime(t.ExpiresAt),
+	)
+	if err != nil {
+		return fmt.Errorf("inserting tree: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		// tree already exists
+		return nil
+	}
+
+	if t.TypedData != nil {
+		const tdq = `
+			INSERT INTO typed_data(
+				root,
+				domain,
+				types,
+				primary_type,
+				messages
+			) VALUES ($1, $2, $3, $4, $5)
+		`
+		_, err = tx.Exec(ctx, tdq,
+			t.Root,
+			t.TypedData.Domain,
+			t.TypedData.Types,
+			t.TypedData.PrimaryType,
+			t.TypedData.Messages,
+		)
+		if err != nil {
+			return fmt.Errorf("inserting typed data: %w", err)
+		}
+	}
+
+	leaves := make([][]any, 0, len(t.Leaves))
+	for i, l := range t.Leaves {
+		leaves = append(leaves, []any{t.Root, i, l})
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"tree_leaves"},
+		[]string{"root", "idx", "leaf"},
+		pgx.CopyFromRows(leaves),
+	)
+	if err != nil {
+		return fmt.Errorf("inserting leaves: %w", err)
+	}
+
+	rows := make([][]any, 0, len(proofHashes))
+	for _, h := range proofHashes {
+		rows = append(rows, []any{t.Root, h})
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"proofs_hashes"},
+		[]string{"root", "hash"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("inserting proof hashes: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
+func (p *PGStore) GetTree(ctx context.Context, root []byte) (TreeRecord, error) {
+	const q = `
+		SELECT
+			ARRAY(
+				SELECT l.leaf FROM tree_leaves l
+				WHERE l.root = t.root
+				ORDER BY l.idx
+			),
+			t.ltd,
+			t.packed,
+			t.salted,
+			t.expires_at,
+			CASE WHEN td.root IS NULL THEN NULL
+			ELSE jsonb_build_object(
+				'domain', td.domain,
+				'types', td.types,
+				'primaryType', td.primary_type,
+				'messages', td.messages
+			) END
+		FROM trees t
+		LEFT JOIN typed_data td ON td.root = t.root
+		WHERE t.root = $1
+	`
+	var (
+		t         = TreeRecord{Root: root}
+		expiresAt *time.Time
+	)
+	err := p.db.QueryRow(ctx, q, root).Scan(
+		&t.Leaves,
+		&t.Ltd,
+		&t.Packed,
+		&t.Salted,
+		&expiresAt,
+		&t.TypedData,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return t, ErrNotFound
+	} else if err != nil {
+		return t, err
+	}
+	if expiresAt != nil {
+		t.ExpiresAt = *expiresAt
+	}
+	return t, nil
+}
+
+func (p *PGStore) ExtendExpiry(ctx context.Context, root []byte, expiresAt time.Time) error {
+	const q = `
+		UPDATE trees
+		SET expires_at = $2
+		WHERE root = $1
+		AND expires_at IS NOT NULL
+		AND ($2::timestamptz IS NULL OR expires_at < $2)
+	`
+	if _, err := p.db.Exec(ctx, q, root, nullTime(expiresAt)); err != nil {
+		return fmt.Errorf("extending expiry: %w", err)
+	}
+	return nil
+}
+
+func (p *PGStore) TouchTree(ctx context.Context, root []byte) error {
+	const q = `
+		UPDATE trees
+		SET accessed_at = now()
+		WHERE root = $1
+		AND accessed_at IS NULL
+	`
+	if _, err := p.db.Exec(ctx, q, root); err != nil {
+		return fmt.Errorf("touching tree: %w", err)
+	}
+	return nil
+}
+
+func (p *PGStore) ExpiredTrees(ctx context.Context, now, unreadBefore time.Time, limit int) ([][]byte, error) {
+	const q = `
+		SELECT root FROM trees
+		WHERE expires_at < $1
+		UNION
+		SELECT root FROM trees
+		WHERE accessed_at IS NULL
+		AND inserted_at < $2
+		LIMIT $3
+	`
+	var (
+		roots [][]byte
+		rb    []byte
+	)
+	_, err := p.db.QueryFunc(ctx, q, []any{now, nullTime(unreadBefore), limit}, []any{&rb}, func(qfr pgx.QueryFuncRow) error {
+		roots = append(roots, append([]byte(nil), rb...))
+		return nil
+	})
+	return roots, err
+}
+
+func (p *PGStore) GetLeaves(ctx context.Context, root []byte, offset, limit int) (TreeRecord, int, error) {
+	const q = `
+		SELECT ltd, packed, salted, leaf_count, expires_at
+		FROM trees
+		WHERE root = $1
+	`
+	var (
+		t         = TreeRecord{Root: root}
+		n         int
+		expiresAt *time.Time
+	)
+	err := p.db.QueryRow(ctx, q, root).Scan(
+		&t.Ltd,
+		&t.Packed,
+		&t.Salted,
+		&n,
+		&expiresAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return t, 0, ErrNotFound
+	} else if err != nil {
+		return t, 0, err
+	}
+	if expiresAt != nil {
+		t.ExpiresAt = *expiresAt
+	}
+
+	const lq = `
+		SELECT leaf
+		FROM tree_leaves
+		WHERE root = $1 AND idx >= $2
+		ORDER BY idx
+		LIMIT $3
+	`
+	var leaf []byte
+	_, err = p.db.QueryFunc(ctx, lq, []any{root, offset, limit}, []any{&leaf}, func(qfr pgx.QueryFuncRow) error {
+		t.Leaves = append(t.Leaves, append([]byte(nil), leaf...))
+		return nil
+	})
+	return t, n, err
+}
+
+func (p *PGStore) RootsByProofHash(ctx context.Context, hash []byte) ([][]byte, error) {
+	const q = `
+		SELECT p.root
+		FROM proofs_hashes p
+		WHERE p.hash = $1
+		AND NOT EXISTS (
+			SELECT 1 FROM tree_owners o
+			WHERE o.root = p.root AND o.hidden
+		)
+		group by 1;
+	`
+	var (
+		roots [][]byte
+		rb    []byte
+	)
+	_, err := p.db.QueryFunc(ctx, q, []any{hash}, []any{&rb}, func(qfr pgx.QueryFuncRow) error {
+		roots = append(roots, append([]byte(nil), rb...))
+		return nil
+	})
+	return roots, err
+}
+
+func (p *PGStore) InsertMetadata(ctx context.Context, root []byte, m MetadataRecord) (bool, error) {
+	const q = `
+		INSERT INTO tree_metadata(
+			root,
+			name,
+			description,
+			creator,
+			tags,
+			chain_id,
+			external_url,
+			edit_token_hash
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (root)
+		DO NOTHING
+	`
+	tag, err := p.db.Exec(ctx, q,
+		root,
+		m.Name,
+		m.Description,
+		creatorBytes(m.Creator),
+		tagsOrEmpty(m.Tags),
+		int64(m.ChainID),
+		m.ExternalURL,
+		m.EditTokenHash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("inserting metadata: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (p *PGStore) GetMetadata(ctx context.Context, root []byte) (MetadataRecord, error) {
+	const q = `
+		SELECT
+			name,
+			description,
+			creator,
+			tags,
+			chain_id,
+			external_url,
+			edit_token_hash
+		FROM tree_metadata
+		WHERE root = $1
+	`
+	var (
+		m       MetadataRecord
+		creator []byte
+		chainID int64
+	)
+	err := p.db.QueryRow(ctx, q, root).Scan(
+		&m.Name,
+		&m.Description,
+		&creator,
+		&m.Tags,
+		&chainID,
+		&m.ExternalURL,
+		&m.EditTokenHash,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return m, ErrNotFound
+	} else if err != nil {
+		return m, err
+	}
+	m.Creator = bytesCreator(creator)
+	m.ChainID = uint64(chainID)
+	return m, nil
+}
+
+func (p *PGStore) UpdateMetadata(ctx context.Context, root []byte, m Metadata) error {
+	const q = `
+		UPDATE tree_metadata SET
+			name = $2,
+			description = $3,
+			creator = $4,
+			tags = $5,
+			chain_id = $6,
+			external_url = $7,
+			updated_at = now()
+		WHERE root = $1
+	`
+	tag, err := p.db.Exec(ctx, q,
+		root,
+		m.Name,
+		m.Description,
+		creatorBytes(m.Creator),
+		tagsOrEmpty(m.Tags),
+		int64(m.ChainID),
+		m.ExternalURL,
+	)
+	if err != nil {
+		return fmt.Errorf("updating metadata: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (p *PGStore) InsertOwner(ctx context.Context, root []byte, owner common.Address) (bool, error) {
+	const q = `
+		INSERT INTO tree_owners(root, owner)
+		VALUES ($1, $2)
+		ON CONFLICT (root)
+		DO NOTHING
+	`
+	tag, err := p.db.Exec(ctx, q, root, owner.Bytes())
+	if err != nil {
+		return false, fmt.Errorf("inserting owner: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (p *PGStore) GetOwner(ctx context.Context, root []byte) (OwnerRecord, error) {
+	const q = `
+		SELECT owner, hidden, signed_at
+		FROM tree_owners
+		WHERE root = $1
+	`
+	var (
+		o     OwnerRecord
+		owner []byte
+	)
+	err := p.db.QueryRow(ctx, q, root).Scan(&owner, &o.Hidden, &o.SignedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return o, ErrNotFound
+	} else if err != nil {
+		return o, err
+	}
+	o.Owner = common.BytesToAddress(owner)
+	return o, nil
+}
+
+func (p *PGStore) UpdateOwner(ctx context.Context, root []byte, hidden bool, signedAt int64) (bool, error) {
+	const q = `
+		UPDATE tree_owners SET
+			hidden = $2,
+			signed_at = $3
+		WHERE root = $1
+		AND signed_at < $3
+	`
+	tag, err := p.db.Exec(ctx, q, root, hidden, signedAt)
+	if err != nil {
+		return false, fmt.Errorf("updating owner: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Tables with rows that belong to a tree
+var treeTables = []string{
+	"trees",
+	"tree_leaves",
+	"proofs_hashes",
+	"typed_data",
+	"tree_metadata",
+	"tree_owners",
+}
+
+func (p *PGStore) DeleteTree(ctx context.Context, root []byte) error {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, t := range treeTables {
+		if _, err := tx.Exec(ctx, "DELETE FROM "+t+" WHERE root = $1", root); err != nil {
+			return fmt.Errorf("deleting from %s: %w", t, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -4,44 +4,111 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/contextwtf/lanyard/merkle"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/jackc/pgx/v4"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
 type getProofResp struct {
-	UnhashedLeaf hexutil.Bytes   `json:"unhashedLeaf"`
-	Proof        []hexutil.Bytes `json:"proof"`
+	UnhashedLeaf hexutil.Bytes             `json:"unhashedLeaf"`
+	Proof        []hexutil.Bytes           `json:"proof"`
+	TypedMessage apitypes.TypedDataMessage `json:"typedMessage,omitempty"`
+
+	// Salt is set for salted trees where the
+	// leaf is keccak256(salt || unhashedLeaf)
+	Salt hexutil.Bytes `json:"salt,omitempty"`
+
+	// Set for address proofs. Every leaf belonging to the
+	// address, the first of which is also returned above.
+	Matches []leafProof `json:"matches,omitempty"`
+}
+
+type leafProof struct {
+	getProofResp
+	Index int `json:"index"`
+
+	// The leaf decoded using the leaf type descriptor
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Filters the leaves of an address proof by a field.
+// For EIP-712 trees field is the name of a message field
+// otherwise it is the position in the leaf type descriptor.
+type proofFilter struct {
+	field, value string
+}
+
+func (f proofFilter) match(ct cachedTree, i int) bool {
+	if f.field == "" {
+		return true
+	}
+	if ct.r.TypedData != nil {
+		v, ok := ct.r.TypedData.Messages[i][f.field]
+		return ok && fieldMatches(fmt.Sprint(v), f.value)
+	}
+	pos, err := strconv.Atoi(f.field)
+	if err != nil {
+		return false
+	}
+	_, pl := splitSalt(ct.r.UnhashedLeaves[i], ct.r.Salted)
+	fields := decodeLeaf(pl, ct.r.Ltd, ct.r.Packed)
+	return pos >= 0 && pos < len(fields) && fieldMatches(fields[pos], f.value)
 }
 
 type cachedTree struct {
 	r getTreeResp
 	t merkle.Tree
+
+	// whether the tree had an owner when it was cached
+	owned bool
 }
 
+// Returns ErrNotFound for hidden trees. The owner is read
+// even when the tree is cached since owners can hide or
+// delete a tree through any server.
 func (s *Server) getCachedTree(ctx context.Context, root common.Hash) (cachedTree, error) {
+	own, err := s.store.GetOwner(ctx, root.Bytes())
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return cachedTree{}, fmt.Errorf("selecting owner: %w", err)
+	} else if err == nil && own.Hidden {
+		return cachedTree{}, ErrNotFound
+	}
+	owned := err == nil
+
 	r, ok := s.tlru.Get(root)
+	if ok && r.owned && !owned {
+		// deleted by its owner through another server
+		s.tlru.Remove(root)
+		return cachedTree{}, ErrNotFound
+	}
 	if ok {
+		s.metrics.cacheHits.Inc()
 		return r, nil
 	}
+	s.metrics.cacheMisses.Inc()
 
-	td, err := getTree(ctx, s.db, root.Bytes())
+	td, err := getTree(ctx, s.store, root.Bytes())
 	if err != nil {
 		return cachedTree{}, err
 	}
+	// cached trees have already been touched
+	s.touchTree(ctx, root.Bytes())
 
 	leaves := [][]byte{}
 	for _, l := range td.UnhashedLeaves {
 		leaves = append(leaves, l[:])
 	}
 
-	t := merkle.New(leaves)
+	t := s.buildTree(ctx, leaves)
 	ct := cachedTree{
-		r: td,
-		t: t,
+		r:     td,
+		t:     t,
+		owned: owned,
 	}
 
 	s.tlru.Add(root, ct)
@@ -54,20 +121,28 @@ func (s *Server) GetProof(w http.ResponseWriter, r *http.Request) {
 		root = common.HexToHash(r.URL.Query().Get("root"))
 		leaf = common.FromHex(r.URL.Query().Get("unhashedLeaf"))
 		addr = common.FromHex(r.URL.Query().Get("address"))
+		pf   = proofFilter{
+			field: r.URL.Query().Get("field"),
+			value: r.URL.Query().Get("value"),
+		}
 	)
 
 	if len(root) == 0 {
-		s.sendJSONError(r, w, nil, http.StatusBadRequest, "missing root")
+		s.sendFieldError(r, w, nil, "root", "missing root")
 		return
 	}
 	if len(leaf) == 0 && len(addr) == 0 {
-		s.sendJSONError(r, w, nil, http.StatusBadRequest, "missing leaf")
+		s.sendFieldError(r, w, nil, "unhashedLeaf", "missing leaf")
+		return
+	}
+	if (pf.field == "") != (pf.value == "") {
+		s.sendJSONError(r, w, nil, http.StatusBadRequest, "field and value must be provided together")
 		return
 	}
 
 	ct, err := s.getCachedTree(ctx, root)
-	if errors.Is(err, pgx.ErrNoRows) {
-		s.sendJSONError(r, w, nil, http.StatusNotFound, "tree not found")
+	if errors.Is(err, ErrNotFound) {
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeTreeNotFound, "tree not found")
 		w.Header().Set("Cache-Control", "public, max-age=60")
 		return
 	} else if err != nil {
@@ -75,35 +150,26 @@ func (s *Server) GetProof(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var (
-		target []byte
-	)
-	// check if leaf is in tree and error if not
-	for _, l := range ct.r.UnhashedLeaves {
-		if len(target) == 0 {
-			if len(leaf) > 0 {
-				if bytes.Equal(l, leaf) {
-					target = l
-				}
-			} else if bytes.Equal(leaf2Addr(l, ct.r.Ltd, ct.r.Packed), addr) {
-				target = l
+	var idxs []int
+	if len(leaf) > 0 {
+		if i := ct.leafIndex(leaf); i >= 0 {
+			idxs = append(idxs, i)
+		}
+	} else {
+		for _, i := range ct.addrIndexes(addr) {
+			if pf.match(ct, i) {
+				idxs = append(idxs, i)
 			}
 		}
 	}
-
-	if len(target) == 0 {
-		s.sendJSONError(r, w, nil, http.StatusNotFound, "leaf not found in tree")
+	if len(idxs) == 0 {
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeLeafNotFound, "leaf not found in tree")
 		return
 	}
 
-	var (
-		p    = ct.t.Proof(ct.t.Index(target))
-		phex = []hexutil.Bytes{}
-	)
-
-	// convert [][]byte to []hexutil.Bytes
-	for _, p := range p {
-		phex = append(phex, p)
+	resp := ct.proof(idxs[0])
+	if len(leaf) == 0 {
+		resp = ct.addrProof(idxs)
 	}
 
 	// cache for 1 year if we're returning an unhashed leaf proof
@@ -113,8 +179,74 @@ func (s *Server) GetProof(w http.ResponseWriter, r *http.Request) {
 	} else {
 		w.Header().Set("Cache-Control", "public, max-age=60")
 	}
-	s.sendJSON(r, w, getProofResp{
-		UnhashedLeaf: target,
+	s.sendJSON(r, w, resp)
+}
+
+// Returns the index of the first leaf matching leaf.
+// Returns -1 if no leaf matches.
+func (ct cachedTree) leafIndex(leaf []byte) int {
+	for i, l := range ct.r.UnhashedLeaves {
+		if _, pl := splitSalt(l, ct.r.Salted); bytes.Equal(pl, leaf) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the index of every leaf belonging to addr.
+func (ct cachedTree) addrIndexes(addr []byte) []int {
+	var idxs []int
+	for i, l := range ct.r.UnhashedLeaves {
+		_, pl := splitSalt(l, ct.r.Salted)
+		if ct.r.TypedData != nil {
+			if bytes.Equal(ct.r.TypedData.addr(i), addr) {
+				idxs = append(idxs, i)
+			}
+		} else if bytes.Equal(leaf2Addr(pl, ct.r.Ltd, ct.r.Packed), addr) {
+			idxs = append(idxs, i)
+		}
+	}
+	return idxs
+}
+
+// Returns the proof of the first leaf in idxs
+// with every leaf in idxs as a match.
+func (ct cachedTree) addrProof(idxs []int) getProofResp {
+	resp := ct.proof(idxs[0])
+	for _, i := range idxs {
+		resp.Matches = append(resp.Matches, ct.leafProof(i))
+	}
+	return resp
+}
+
+func (ct cachedTree) leafProof(idx int) leafProof {
+	lp := leafProof{getProofResp: ct.proof(idx), Index: idx}
+	if ct.r.TypedData == nil {
+		lp.Fields = decodeLeaf(lp.UnhashedLeaf, ct.r.Ltd, ct.r.Packed)
+	}
+	return lp
+}
+
+func (ct cachedTree) proof(idx int) getProofResp {
+	var (
+		phex = []hexutil.Bytes{}
+		msg  apitypes.TypedDataMessage
+
+		salt, payload = splitSalt(ct.r.UnhashedLeaves[idx], ct.r.Salted)
+	)
+	if ct.r.TypedData != nil {
+		msg = ct.r.TypedData.Messages[idx]
+	}
+
+	// convert [][]byte to []hexutil.Bytes
+	for _, p := range ct.t.Proof(idx) {
+		phex = append(phex, p)
+	}
+
+	return getProofResp{
+		UnhashedLeaf: payload,
 		Proof:        phex,
-	})
+		TypedMessage: msg,
+		Salt:         salt,
+	}
 }
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const maxBatchProofs = 10000
+
+type batchProofReq struct {
+	Root      hexutil.Bytes   `json:"root"`
+	Leaves    []hexutil.Bytes `json:"unhashedLeaves"`
+	Addresses []hexutil.Bytes `json:"addresses"`
+}
+
+// A proof in a batch. Items that are not in the tree
+// have found set to false and only echo the request.
+// Addresses include every leaf belonging to the
+// address in matches, like GET /api/v1/proof.
+type batchProof struct {
+	getProofResp
+	Address hexutil.Bytes `json:"address,omitempty"`
+	Found   bool          `json:"found"`
+}
+
+// Proofs are returned in the same order as the request.
+type batchProofResp struct {
+	Leaves    []batchProof `json:"unhashedLeaves"`
+	Addresses []batchProof `json:"addresses"`
+}
+
+// Indexes the first leaf for each payload and every leaf
+// for each address so that each item in a batch is a
+// map lookup.
+func (ct cachedTree) leafIndexes(byAddr bool) (map[string]int, map[common.Address][]int) {
+	var (
+		leaves = make(map[string]int, len(ct.r.UnhashedLeaves))
+		addrs  = map[common.Address][]int{}
+	)
+	for i, l := range ct.r.UnhashedLeaves {
+		_, pl := splitSalt(l, ct.r.Salted)
+		if _, ok := leaves[string(pl)]; !ok {
+			leaves[string(pl)] = i
+		}
+		if !byAddr {
+			continue
+		}
+		var a []byte
+		if ct.r.TypedData != nil {
+			a = ct.r.TypedData.addr(i)
+		} else {
+			a = leaf2Addr(pl, ct.r.Ltd, ct.r.Packed)
+		}
+		if len(a) != common.AddressLength {
+			continue
+		}
+		addr := common.BytesToAddress(a)
+		addrs[addr] = append(addrs[addr], i)
+	}
+	return leaves, addrs
+}
+
+func (s *Server) GetProofs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendJSONError(r, w, nil, http.StatusMethodNotAllowed, "unsupported method")
+		return
+	}
+
+	var (
+		req batchProofReq
+		ctx = r.Context()
+	)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendBodyError(r, w, err, "invalid request body")
+		return
+	}
+	if len(req.Root) == 0 {
+		s.sendFieldError(r, w, nil, "root", "missing root")
+		return
+	}
+	switch n := len(req.Leaves) + len(req.Addresses); {
+	case n == 0:
+		s.sendJSONError(r, w, nil, http.StatusBadRequest, "missing leaves or addresses")
+		return
+	case n > maxBatchProofs:
+		s.sendCodedError(r, w, nil, http.StatusBadRequest, CodeLimitExceeded, fmt.Sprintf("at most %d proofs can be requested at once", maxBatchProofs))
+		return
+	}
+
+	ct, err := s.getCachedTree(ctx, common.BytesToHash(req.Root))
+	if errors.Is(err, ErrNotFound) {
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeTreeNotFound, "tree not found")
+		return
+	} else if err != nil {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting proof")
+		return
+	}
+
+	var (
+		byLeaf, byAddr = ct.leafIndexes(len(req.Addresses) > 0)
+		resp           = batchProofResp{
+			Leaves:    make([]batchProof, 0, len(req.Leaves)),
+			Addresses: make([]batchProof, 0, len(req.Addresses)),
+		}
+	)
+	for _, l := range req.Leaves {
+		i, ok := byLeaf[string(l)]
+		if !ok {
+			resp.Leaves = append(resp.Leaves, batchProof{getProofResp: getProofResp{UnhashedLeaf: l}})
+			continue
+		}
+		resp.Leaves = append(resp.Leaves, batchProof{getProofResp: ct.proof(i), Found: true})
+	}
+	for _, a := range req.Addresses {
+		idxs := byAddr[common.BytesToAddress(a)]
+		if len(idxs) == 0 || len(a) != common.AddressLength {
+			resp.Addresses = append(resp.Addresses, batchProof{Address: a})
+			continue
+		}
+		resp.Addresses = append(resp.Addresses, batchProof{getProofResp: ct.addrProof(idxs), Address: a, Found: true})
+	}
+
+	s.sendJSON(r, w, resp)
+}
+
+// A line of the NDJSON proof stream
+type streamProof struct {
+	Index        int             `json:"index"`
+	UnhashedLeaf hexutil.Bytes   `json:"unhashedLeaf"`
+	Address      hexutil.Bytes   `json:"address,omitempty"`
+	Proof        []hexutil.Bytes `json:"proof"`
+}
+
+// Number of lines written between flushes
+const streamFlushEvery = 1000
+
+// Streams the proof of every leaf as newline delimited
+// JSON in the order of the leaves. Each line is written as
+// it is encoded so the response is never held in memory.
+// Salted trees are refused since streaming them would
+// publish every leaf and salt at once.
+func (s *Server) StreamProofs(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		root = r.URL.Query().Get("root")
+	)
+	if root == "" {
+		s.sendFieldError(r, w, nil, "root", "missing root")
+		return
+	}
+
+	ct, err := s.getCachedTree(ctx, common.HexToHash(root))
+	if errors.Is(err, ErrNotFound) {
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeTreeNotFound, "tree not found for root")
+		return
+	} else if err != nil {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting tree")
+		return
+	}
+	if ct.r.Salted {
+		s.sendJSONError(r, w, nil, http.StatusBadRequest, "salted trees cannot be streamed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+
+	var (
+		enc    = json.NewEncoder(w)
+		f, _   = w.(http.Flusher)
+		proofs = ct.t.LeafProofs()
+		line   streamProof
+		phex   []hexutil.Bytes
+	)
+	for i, p := range proofs {
+		if ctx.Err() != nil {
+			return // client went away
+		}
+
+		phex = phex[:0]
+		for _, h := range p {
+			phex = append(phex, h)
+		}
+		line = streamProof{
+			Index:        i,
+			UnhashedLeaf: ct.r.UnhashedLeaves[i],
+			Proof:        phex,
+		}
+		if ct.r.TypedData != nil {
+			line.Address = ct.r.TypedData.addr(i)
+		} else if a := leaf2Addr(line.UnhashedLeaf, ct.r.Ltd, ct.r.Packed); len(a) == common.AddressLength {
+			line.Address = a
+		}
+		if err := enc.Encode(line); err != nil {
+			return
+		}
+		if f != nil && (i+1)%streamFlushEvery == 0 {
+			f.Flush()
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/contextwtf/lanyard/merkle"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestGetProofs(t *testing.T) {
+	var (
+		h      = New(NewMemStore()).Handler("test", "")
+		leaves = []string{
+			"0x0000000000000000000000000000000000000001",
+			"0x0000000000000000000000000000000000000002",
+			"0x0000000000000000000000000000000000000003",
+		}
+		created createTreeResp
+	)
+	code := do(t, h, http.MethodPost, "/api/v1/tree", createTreeReq{Leaves: leaves}, &created)
+	if code != http.StatusOK {
+		t.Fatalf("create: expected: %d got: %d", http.StatusOK, code)
+	}
+
+	var (
+		missing = hexutil.Bytes(hexutil.MustDecode("0x0000000000000000000000000000000000000004"))
+		req     = batchProofReq{
+			Root:      hexutil.MustDecode(created.MerkleRoot),
+			Leaves:    []hexutil.Bytes{hexutil.MustDecode(leaves[2]), missing},
+			Addresses: []hexutil.Bytes{missing, hexutil.MustDecode(leaves[0])},
+		}
+		resp batchProofResp
+	)
+	code = do(t, h, http.MethodPost, "/api/v1/proofs", req, &resp)
+	if code != http.StatusOK {
+		t.Fatalf("batch: expected: %d got: %d", http.StatusOK, code)
+	}
+
+	cases := []struct {
+		got   batchProof
+		leaf  string
+		found bool
+	}{
+		{resp.Leaves[0], leaves[2], true},
+		{resp.Leaves[1], missing.String(), false},
+		{resp.Addresses[0], "0x", false},
+		{resp.Addresses[1], leaves[0], true},
+	}
+	for i, c := range cases {
+		if c.got.Found != c.found {
+			t.Errorf("%d: expected found: %t got: %t", i, c.found, c.got.Found)
+		}
+		if c.got.UnhashedLeaf.String() != c.leaf {
+			t.Errorf("%d: expected: %s got: %s", i, c.leaf, c.got.UnhashedLeaf)
+		}
+		if c.found && len(c.got.Proof) == 0 {
+			t.Errorf("%d: expected a proof", i)
+		}
+	}
+
+	code = do(t, h, http.MethodPost, "/api/v1/proofs", batchProofReq{Root: req.Root}, nil)
+	if code != http.StatusBadRequest {
+		t.Errorf("empty batch: expected: %d got: %d", http.StatusBadRequest, code)
+	}
+}
+
+func TestGetProofMatches(t *testing.T) {
+	var (
+		h    = New(NewMemStore()).Handler("test", "")
+		addr = "0x0000000000000000000000000000000000000001"
+		// abi.encode(address, uint256) for two tiers of
+		// the same address and one other address
+		leaves = []string{
+			"0x00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000001",
+			"0x00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000001",
+			"0x00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002",
+		}
+		created createTreeResp
+	)
+	req := createTreeReq{Leaves: leaves, Ltd: []string{"address", "uint256"}}
+	code := do(t, h, http.MethodPost, "/api/v1/tree", req, &created)
+	if code != http.StatusOK {
+		t.Fatalf("create: expected: %d got: %d", http.StatusOK, code)
+	}
+
+	cases := []struct {
+		query   string
+		code    int
+		indexes []int
+	}{
+		{"", http.StatusOK, []int{0, 2}},
+		{"&field=1&value=2", http.StatusOK, []int{2}},
+		{"&field=1&value=0x01", http.StatusOK, []int{0}},
+		{"&field=1&value=3", http.StatusNotFound, nil},
+		{"&field=1", http.StatusBadRequest, nil},
+	}
+	for _, c := range cases {
+		var resp getProofResp
+		path := "/api/v1/proof?root=" + created.MerkleRoot + "&address=" + addr + c.query
+		code := do(t, h, http.MethodGet, path, nil, &resp)
+		if code != c.code {
+			t.Errorf("%q: expected: %d got: %d", c.query, c.code, code)
+			continue
+		}
+		if len(resp.Matches) != len(c.indexes) {
+			t.Errorf("%q: expected: %d matches got: %d", c.query, len(c.indexes), len(resp.Matches))
+			continue
+		}
+		for i, m := range resp.Matches {
+			if m.Index != c.indexes[i] {
+				t.Errorf("%q: expected index: %d got: %d", c.query, c.indexes[i], m.Index)
+			}
+			if len(m.Fields) != 2 || m.Fields[0] != addr || len(m.Proof) == 0 {
+				t.Errorf("%q: unexpected match %+v", c.query, m)
+			}
+		}
+		if len(c.indexes) > 0 && resp.UnhashedLeaf.String() != leaves[c.indexes[0]] {
+			t.Errorf("%q: expected first match: %s got: %s", c.query, leaves[c.indexes[0]], resp.UnhashedLeaf)
+		}
+	}
+}
+
+func TestGetProofsMatches(t *testing.T) {
+	var (
+		h    = New(NewMemStore()).Handler("test", "")
+		addr = hexutil.MustDecode("0x0000000000000000000000000000000000000001")
+		// abi.encode(address, uint256) for two tiers of
+		// the same address and one other address
+		leaves = []string{
+			"0x00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000001",
+			"0x00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000001",
+			"0x00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002",
+		}
+		created createTreeResp
+	)
+	req := createTreeReq{Leaves: leaves, Ltd: []string{"address", "uint256"}}
+	if code := do(t, h, http.MethodPost, "/api/v1/tree", req, &created); code != http.StatusOK {
+		t.Fatalf("create: expected: %d got: %d", http.StatusOK, code)
+	}
+
+	var resp batchProofResp
+	breq := batchProofReq{
+		Root:      hexutil.MustDecode(created.MerkleRoot),
+		Addresses: []hexutil.Bytes{addr, hexutil.MustDecode("0x0000000000000000000000000000000000000002")},
+	}
+	if code := do(t, h, http.MethodPost, "/api/v1/proofs", breq, &resp); code != http.StatusOK {
+		t.Fatalf("batch: expected: %d got: %d", http.StatusOK, code)
+	}
+
+	cases := []struct {
+		got     batchProof
+		indexes []int
+	}{
+		{resp.Addresses[0], []int{0, 2}},
+		{resp.Addresses[1], []int{1}},
+	}
+	for i, c := range cases {
+		if !c.got.Found || len(c.got.Matches) != len(c.indexes) {
+			t.Errorf("%d: expected: %d matches got: %+v", i, len(c.indexes), c.got)
+			continue
+		}
+		for j, m := range c.got.Matches {
+			if m.Index != c.indexes[j] || len(m.Proof) == 0 {
+				t.Errorf("%d: expected index: %d got: %+v", i, c.indexes[j], m)
+			}
+		}
+		if c.got.UnhashedLeaf.String() != leaves[c.indexes[0]] {
+			t.Errorf("%d: expected first match: %s got: %s", i, leaves[c.indexes[0]], c.got.UnhashedLeaf)
+		}
+	}
+}
+
+func TestStreamProofs(t *testing.T) {
+	var (
+		h      = New(NewMemStore()).Handler("test", "")
+		leaves = []string{
+			"0x0000000000000000000000000000000000000001",
+			"0x0000000000000000000000000000000000000002",
+			"0x0000000000000000000000000000000000000003",
+		}
+		created createTreeResp
+	)
+	code := do(t, h, http.MethodPost, "/api/v1/tree", createTreeReq{Leaves: leaves}, &created)
+	if code != http.StatusOK {
+		t.Fatalf("create: expected: %d got: %d", http.StatusOK, code)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tree/proofs?root="+created.MerkleRoot, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("stream: expected: %d got: %d", http.StatusOK, w.Code)
+	}
+
+	var (
+		root = hexutil.MustDecode(created.MerkleRoot)
+		dec  = json.NewDecoder(w.Body)
+		n    int
+	)
+	for dec.More() {
+		var line streamProof
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		if line.Index != n || line.UnhashedLeaf.String() != leaves[n] {
+			t.Errorf("expected: %d %s got: %d %s", n, leaves[n], line.Index, line.UnhashedLeaf)
+		}
+		if line.Address.String() != leaves[n] {
+			t.Errorf("expected address: %s got: %s", leaves[n], line.Address)
+		}
+		var proof [][]byte
+		for _, p := range line.Proof {
+			proof = append(proof, p)
+		}
+		if !merkle.Valid(root, proof, line.UnhashedLeaf) {
+			t.Errorf("invalid proof for leaf %d", n)
+		}
+		n++
+	}
+	if n != len(leaves) {
+		t.Errorf("expected: %d lines got: %d", len(leaves), n)
+	}
+}
+
+func TestStreamProofsSalted(t *testing.T) {
+	var (
+		h       = New(NewMemStore()).Handler("test", "")
+		created = createTree(t, h, createTreeReq{Leaves: []string{"0x01", "0x02"}, Salted: true})
+	)
+	code := do(t, h, http.MethodGet, "/api/v1/tree/proofs?root="+created.MerkleRoot, nil, nil)
+	if code != http.StatusBadRequest {
+		t.Errorf("expected: %d got: %d", http.StatusBadRequest, code)
+	}
+}
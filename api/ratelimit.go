@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// A token bucket that holds up to Burst
+// tokens and refills at Rate tokens per second.
+// A zero Rate means requests are not limited.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Parses limits like "60/m", meaning 60 requests
+// per minute with bursts of up to 60 requests.
+// The unit may be s, m, h or d.
+func ParseRateLimit(s string) (RateLimit, error) {
+	n, unit, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q must be of the form count/unit", s)
+	}
+	count, err := strconv.Atoi(n)
+	if err != nil || count < 1 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit count %q", n)
+	}
+	var d time.Duration
+	switch unit {
+	case "s":
+		d = time.Second
+	case "m":
+		d = time.Minute
+	case "h":
+		d = time.Hour
+	case "d":
+		d = 24 * time.Hour
+	default:
+		return RateLimit{}, fmt.Errorf("invalid rate limit unit %q", unit)
+	}
+	return RateLimit{Rate: float64(count) / d.Seconds(), Burst: count}, nil
+}
+
+// Limits for requests that create trees
+// and for every other request.
+type RateLimits struct {
+	Create RateLimit
+	Read   RateLimit
+}
+
+// Configures the middleware added by [Server.SetRateLimits]
+type RateLimitConfig struct {
+	// Limits for each IP address
+	RateLimits
+
+	// Limits for requests with one of these keys in the
+	// X-Api-Key header. Requests with other keys are
+	// limited by IP address.
+	Keys map[string]RateLimits
+
+	// Set when the server is behind a proxy, such as Fastly,
+	// that sets the fastly-client-ip or x-forwarded-for
+	// headers. Otherwise clients could pick their own bucket
+	// by setting those headers, so they are ignored and
+	// clients are limited by the address they connect from.
+	TrustProxy bool
+
+	// Holds the buckets. Defaults to a [MemRateStore].
+	// Use a shared store such as [PGRateStore] to
+	// enforce limits across several servers.
+	Store RateStore
+}
+
+// A RateStore holds a token bucket for each key
+type RateStore interface {
+	// Takes a token from the bucket for key. Returns zero if
+	// a token was taken or how long until one is available.
+	Take(ctx context.Context, key string, l RateLimit) (time.Duration, error)
+}
+
+// Limits each client to the configured rates. Without
+// this the server does not limit requests.
+func (s *Server) SetRateLimits(c RateLimitConfig) {
+	if c.Store == nil {
+		c.Store = NewMemRateStore()
+	}
+	s.rateLimits = &c
+}
+
+// Paths that create trees when posted to
+var createPaths = map[string]bool{
+	"/api/v1/tree":        true,
+	"/api/v1/tree/import": true,
+	"/api/v1/airdrop":     true,
+}
+
+func (s *Server) rateLimitHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := s.rateLimits
+		if c == nil || r.URL.Path == "/health" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		ip := remoteIP(r)
+		if c.TrustProxy {
+			ip = ipFromRequest(r)
+		}
+		var (
+			limits = c.RateLimits
+			client = "ip:" + ip
+		)
+		if k := r.Header.Get("X-Api-Key"); k != "" {
+			if kl, ok := c.Keys[k]; ok {
+				limits = kl
+				client = "key:" + k
+			}
+		}
+		kind, l := "read:", limits.Read
+		if r.Method == http.MethodPost && createPaths[r.URL.Path] {
+			kind, l = "create:", limits.Create
+		}
+		if l.Rate <= 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		wait, err := c.Store.Take(r.Context(), kind+client, l)
+		if err != nil {
+			// an unavailable store shouldn't take down the api
+			log.Ctx(r.Context()).Err(err).Msg("rate limiting")
+		} else if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			s.sendJSONError(r, w, nil, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Returns the tokens in a bucket after refilling it for
+// elapsed and how long until it holds one token.
+func refill(tokens float64, elapsed time.Duration, l RateLimit) (float64, time.Duration) {
+	tokens = math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.Rate)
+	if tokens >= 1 {
+		return tokens, 0
+	}
+	return tokens, time.Duration((1 - tokens) / l.Rate * float64(time.Second))
+}
+
+type memBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemRateStore is a [RateStore] for a single server.
+// The least recently used buckets are dropped once
+// there are too many, which refills them.
+type MemRateStore struct {
+	mu      sync.Mutex
+	buckets *lru.Cache[string, memBucket]
+}
+
+func NewMemRateStore() *MemRateStore {
+	l, err := lru.New[string, memBucket](100000)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create lru cache")
+	}
+	return &MemRateStore{buckets: l}
+}
+
+func (m *MemRateStore) Take(ctx context.Context, key string, l RateLimit) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var (
+		now   = time.Now()
+		b, ok = m.buckets.Get(key)
+	)
+	if !ok {
+		b = memBucket{tokens: float64(l.Burst), updated: now}
+	}
+	tokens, wait := refill(b.tokens, now.Sub(b.updated), l)
+	if wait == 0 {
+		tokens--
+	}
+	m.buckets.Add(key, memBucket{tokens: tokens, updated: now})
+	return wait, nil
+}
+
+// PGRateStore is a [RateStore] backed by the rate_limits
+// table so that limits are shared by every server using
+// the database. Times come from the database so that
+// servers don't need synchronised clocks.
+type PGRateStore struct {
+	db *pgxpool.Pool
+}
+
+func NewPGRateStore(db *pgxpool.Pool) *PGRateStore {
+	return &PGRateStore{db: db}
+}
+
+func (p *PGRateStore) Take(ctx context.Context, key string, l RateLimit) (time.Duration, error) {
+	// the update only happens when the refilled bucket has
+	// a token and the row lock makes concurrent takes safe
+	const q = `
+		INSERT INTO rate_limits AS r (key, tokens, updated_at)
+		VALUES ($1, $2::float8 - 1, now())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = LEAST($2::float8, r.tokens + EXTRACT(EPOCH FROM now() - r.updated_at)::float8 * $3::float8) - 1,
+			updated_at = now()
+		WHERE LEAST($2::float8, r.tokens + EXTRACT(EPOCH FROM now() - r.updated_at)::float8 * $3::float8) >= 1
+		RETURNING tokens
+	`
+	var tokens float64
+	err := p.db.QueryRow(ctx, q, key, l.Burst, l.Rate).Scan(&tokens)
+	if err == nil {
+		return 0, nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("taking token: %w", err)
+	}
+
+	const sq = `
+		SELECT tokens, EXTRACT(EPOCH FROM now() - updated_at)::float8
+		FROM rate_limits
+		WHERE key = $1
+	`
+	var elapsed float64
+	if err := p.db.QueryRow(ctx, sq, key).Scan(&tokens, &elapsed); err != nil {
+		return 0, fmt.Errorf("selecting bucket: %w", err)
+	}
+	_, wait := refill(tokens, time.Duration(elapsed*float64(time.Second)), l)
+	if wait == 0 {
+		// refilled since the update, try again later
+		wait = time.Second
+	}
+	return wait, nil
+}
+
+// Deletes buckets that haven't been used for a day.
+// Limits from [ParseRateLimit] refill within a day
+// so this is the same as leaving them full.
+func (p *PGRateStore) Prune(ctx context.Context) error {
+	const q = `
+		DELETE FROM rate_limits
+		WHERE updated_at < now() - interval '1 day'
+	`
+	if _, err := p.db.Exec(ctx, q); err != nil {
+		return fmt.Errorf("pruning rate limits: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	cases := []struct {
+		s    string
+		want RateLimit
+		err  bool
+	}{
+		{"60/m", RateLimit{Rate: 1, Burst: 60}, false},
+		{"2/s", RateLimit{Rate: 2, Burst: 2}, false},
+		{"60", RateLimit{}, true},
+		{"0/s", RateLimit{}, true},
+		{"1/w", RateLimit{}, true},
+	}
+	for _, c := range cases {
+		got, err := ParseRateLimit(c.s)
+		if (err != nil) != c.err {
+			t.Errorf("%s: expected error: %t got: %v", c.s, c.err, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: expected: %+v got: %+v", c.s, c.want, got)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s := New(NewMemStore())
+	s.SetRateLimits(RateLimitConfig{
+		RateLimits: RateLimits{
+			Create: RateLimit{Rate: 0.001, Burst: 1},
+			Read:   RateLimit{Rate: 0.001, Burst: 2},
+		},
+		Keys: map[string]RateLimits{
+			"partner": {Read: RateLimit{Rate: 0.001, Burst: 3}},
+		},
+		TrustProxy: true,
+	})
+	h := s.Handler("test", "")
+
+	const body = `{"unhashedLeaves": ["0x01", "0x02"]}`
+	cases := []struct {
+		method, path, ip, key string
+		code                  int
+	}{
+		{http.MethodPost, "/api/v1/tree", "1.1.1.1", "", http.StatusOK},
+		{http.MethodPost, "/api/v1/tree", "1.1.1.1", "", http.StatusTooManyRequests},
+		// reads have their own bucket
+		{http.MethodGet, "/api/v1/tree", "1.1.1.1", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/tree", "1.1.1.1", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/tree", "1.1.1.1", "", http.StatusTooManyRequests},
+		{http.MethodGet, "/health", "1.1.1.1", "", http.StatusOK},
+		// as do other ips
+		{http.MethodPost, "/api/v1/tree", "2.2.2.2", "", http.StatusOK},
+		// known keys have their own limits
+		{http.MethodGet, "/api/v1/tree", "1.1.1.1", "partner", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/tree", "1.1.1.1", "partner", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/tree", "1.1.1.1", "partner", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/tree", "1.1.1.1", "partner", http.StatusTooManyRequests},
+		{http.MethodGet, "/api/v1/tree", "1.1.1.1", "unknown", http.StatusTooManyRequests},
+	}
+	for i, c := range cases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(c.method, c.path, strings.NewReader(body))
+		r.Header.Set("fastly-client-ip", c.ip)
+		if c.key != "" {
+			r.Header.Set("X-Api-Key", c.key)
+		}
+		h.ServeHTTP(w, r)
+		if w.Code != c.code {
+			t.Errorf("%d: expected: %d got: %d", i, c.code, w.Code)
+		}
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("%d: expected Retry-After", i)
+		}
+	}
+}
+
+func TestRateLimitUntrustedProxy(t *testing.T) {
+	s := New(NewMemStore())
+	s.SetRateLimits(RateLimitConfig{
+		RateLimits: RateLimits{Read: RateLimit{Rate: 0.001, Burst: 1}},
+	})
+	h := s.Handler("test", "")
+
+	cases := []struct {
+		header, ip string
+		code       int
+	}{
+		{"fastly-client-ip", "1.1.1.1", http.StatusBadRequest},
+		// the headers don't get a new bucket
+		{"fastly-client-ip", "2.2.2.2", http.StatusTooManyRequests},
+		{"x-forwarded-for", "3.3.3.3", http.StatusTooManyRequests},
+	}
+	for i, c := range cases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/tree", nil)
+		r.Header.Set(c.header, c.ip)
+		h.ServeHTTP(w, r)
+		if w.Code != c.code {
+			t.Errorf("%d: expected: %d got: %d", i, c.code, w.Code)
+		}
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/jackc/pgx/v4"
 )
 
 func (s *Server) GetRoot(w http.ResponseWriter, r *http.Request) {
@@ -36,36 +35,25 @@ func (s *Server) GetRoot(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(pb) == 0 || err != nil {
-		s.sendJSONError(r, w, nil, http.StatusBadRequest, "missing or malformed list of proofs")
+		s.sendFieldError(r, w, nil, "proof", "missing or malformed list of proofs")
 		return
 	}
 
-	const q = `
-		SELECT root
-		FROM proofs_hashes
-		WHERE hash = $1
-		group by 1;
-	`
-	var (
-		roots []hexutil.Bytes
-		rb    hexutil.Bytes
-		ph    = hashProof(pb)
-	)
-
-	_, err = s.db.QueryFunc(ctx, q, []interface{}{&ph}, []interface{}{&rb}, func(qfr pgx.QueryFuncRow) error {
-		roots = append(roots, rb)
-		return nil
-	})
-
+	rbs, err := s.store.RootsByProofHash(ctx, hashProof(pb))
 	if err != nil {
 		s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting root")
 		return
-	} else if len(roots) == 0 { // db.QueryFunc doesn't return pgx.ErrNoRows
+	} else if len(rbs) == 0 {
 		w.Header().Set("Cache-Control", "public, max-age=60")
-		s.sendJSONError(r, w, nil, http.StatusNotFound, "root not found for proofs")
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeProofNotFound, "root not found for proofs")
 		return
 	}
 
+	roots := make([]hexutil.Bytes, 0, len(rbs))
+	for _, rb := range rbs {
+		roots = append(roots, rb)
+	}
+
 	w.Header().Set("Cache-Control", "public, max-age=3600")
 
 	if strings.HasPrefix(r.URL.Path, "/api/v1/roots") {
@@ -34,12 +34,67 @@ CREATE TABLE public.migrations (
 
 
 
+CREATE UNLOGGED TABLE public.rate_limits (
+    key text NOT NULL,
+    tokens double precision NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+
+
+CREATE TABLE public.tree_metadata (
+    root bytea NOT NULL,
+    name text DEFAULT ''::text NOT NULL,
+    description text DEFAULT ''::text NOT NULL,
+    creator bytea,
+    tags text[] DEFAULT '{}'::text[] NOT NULL,
+    chain_id bigint DEFAULT 0 NOT NULL,
+    external_url text DEFAULT ''::text NOT NULL,
+    edit_token_hash bytea,
+    inserted_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+
 CREATE TABLE public.trees (
     root bytea NOT NULL,
-    unhashed_leaves bytea[] NOT NULL,
     ltd text[],
     packed boolean,
-    proofs jsonb
+    proofs jsonb,
+    salted boolean DEFAULT false NOT NULL,
+    leaf_count integer NOT NULL,
+    inserted_at timestamp with time zone DEFAULT now() NOT NULL,
+    expires_at timestamp with time zone,
+    accessed_at timestamp with time zone
+);
+
+
+
+CREATE TABLE public.tree_leaves (
+    root bytea NOT NULL,
+    idx integer NOT NULL,
+    leaf bytea NOT NULL
+);
+
+
+
+CREATE TABLE public.tree_owners (
+    root bytea NOT NULL,
+    owner bytea NOT NULL,
+    hidden boolean DEFAULT false NOT NULL,
+    signed_at bigint DEFAULT 0 NOT NULL,
+    inserted_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+
+CREATE TABLE public.typed_data (
+    root bytea NOT NULL,
+    types jsonb NOT NULL,
+    primary_type text NOT NULL,
+    messages jsonb NOT NULL,
+    domain jsonb NOT NULL
 );
 
 
@@ -49,9 +104,50 @@ ALTER TABLE ONLY public.trees
 
 
 
+ALTER TABLE ONLY public.tree_leaves
+    ADD CONSTRAINT tree_leaves_pkey PRIMARY KEY (root, idx);
+
+
+
+ALTER TABLE ONLY public.rate_limits
+    ADD CONSTRAINT rate_limits_pkey PRIMARY KEY (key);
+
+
+
+ALTER TABLE ONLY public.tree_metadata
+    ADD CONSTRAINT tree_metadata_pkey PRIMARY KEY (root);
+
+
+
+ALTER TABLE ONLY public.tree_owners
+    ADD CONSTRAINT tree_owners_pkey PRIMARY KEY (root);
+
+
+
+ALTER TABLE ONLY public.typed_data
+    ADD CONSTRAINT typed_data_pkey PRIMARY KEY (root);
+
+
+
 ALTER TABLE ONLY public.migrations
     ADD CONSTRAINT migrations_pkey PRIMARY KEY (filename);
 
 
 
+CREATE INDEX tree_metadata_creator_idx ON public.tree_metadata USING btree (creator);
+
+
+
+CREATE INDEX tree_metadata_tags_idx ON public.tree_metadata USING gin (tags);
+
+
+
+CREATE INDEX tree_owners_owner_idx ON public.tree_owners USING btree (owner);
+
+
+
+CREATE INDEX trees_expires_at_idx ON public.trees USING btree (expires_at) WHERE (expires_at IS NOT NULL);
+
+
 
+CREATE INDEX trees_unaccessed_idx ON public.trees USING btree (inserted_at) WHERE (accessed_at IS NULL);
@@ -0,0 +1,547 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SQLiteStore is a [Store] backed by SQLite.
+// See migrations.SQLite for the tables it uses.
+// Arrays and jsonb columns from the Postgres
+// schema are stored as JSON text.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+func (s *SQLiteStore) TreeExists(ctx context.Context, root []byte) (bool, error) {
+	const q = `
+	select exists(
+		select 1 from trees where root = ?
+	)
+	`
+	var exists bool
+	err := s.db.QueryRowContext(ctx, q, root).Scan(&exists)
+	return exists, err
+}
+
+func (s *SQLiteStore) InsertTree(ctx context.Context, t TreeRecord, proofHashes [][]byte) error {
+	const q = `
+		INSERT INTO trees(
+			root,
+			ltd,
+			packed,
+			salted,
+			leaf_count,
+			expires_at
+		) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (root)
+		DO NOTHING
+	`
+
+	var (
+		ltd []byte
+		err error
+	)
+	if t.Ltd != nil {
+		ltd, err = json.Marshal(t.Ltd)
+		if err != nil {
+			return fmt.Errorf("encoding ltd: %w", err)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, q,
+		t.Root,
+		nullString(ltd),
+		t.Packed,
+		t.Salted,
+		len(t.Leaves),
+		nullUnix(t.ExpiresAt),
+	)
+	if err != nil {
+		return fmt.Errorf("inserting tree: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("inserting tree: %w", err)
+	} else if n == 0 {
+		// tree already exists
+		return nil
+	}
+
+	if t.TypedData != nil {
+		const tdq = `
+			INSERT INTO typed_data(
+				root,
+				domain,
+				types,
+				primary_type,
+				messages
+			) VALUES (?, ?, ?, ?, ?)
+		`
+		domain, err := json.Marshal(t.TypedData.Domain)
+		if err != nil {
+			return fmt.Errorf("encoding domain: %w", err)
+		}
+		types, err := json.Marshal(t.TypedData.Types)
+		if err != nil {
+			return fmt.Errorf("encoding types: %w", err)
+		}
+		msgs, err := json.Marshal(t.TypedData.Messages)
+		if err != nil {
+			return fmt.Errorf("encoding messages: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, tdq,
+			t.Root,
+			string(domain),
+			string(types),
+			t.TypedData.PrimaryType,
+			string(msgs),
+		)
+		if err != nil {
+			return fmt.Errorf("inserting typed data: %w", err)
+		}
+	}
+
+	lstmt, err := tx.PrepareContext(ctx, `INSERT INTO tree_leaves(root, idx, leaf) VALUES (?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("preparing leaves: %w", err)
+	}
+	defer lstmt.Close()
+	for i, l := range t.Leaves {
+		if _, err := lstmt.ExecContext(ctx, t.Root, i, l); err != nil {
+			return fmt.Errorf("inserting leaves: %w", err)
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO proofs_hashes(root, hash) VALUES (?, ?)`)
+	if err != nil {
+		return fmt.Errorf("preparing proof hashes: %w", err)
+	}
+	defer stmt.Close()
+	for _, h := range proofHashes {
+		if _, err := stmt.ExecContext(ctx, t.Root, h); err != nil {
+			return fmt.Errorf("inserting proof hashes: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
+func nullString(b []byte) sql.NullString {
+	return sql.NullString{String: string(b), Valid: b != nil}
+}
+
+func nullUnix(t time.Time) sql.NullInt64 {
+	return sql.NullInt64{Int64: t.Unix(), Valid: !t.IsZero()}
+}
+
+func unixTime(n sql.NullInt64) time.Time {
+	if !n.Valid {
+		return time.Time{}
+	}
+	return time.Unix(n.Int64, 0)
+}
+
+func (s *SQLiteStore) GetTree(ctx context.Context, root []byte) (TreeRecord, error) {
+	const q = `
+		SELECT
+			t.ltd,
+			t.packed,
+			t.salted,
+			t.expires_at,
+			td.domain,
+			td.types,
+			td.primary_type,
+			td.messages
+		FROM trees t
+		LEFT JOIN typed_data td ON td.root = t.root
+		WHERE t.root = ?
+	`
+	var (
+		t                       = TreeRecord{Root: root}
+		ltd                     sql.NullString
+		domain, types, pt, msgs sql.NullString
+		expiresAt               sql.NullInt64
+	)
+	err := s.db.QueryRowContext(ctx, q, root).Scan(
+		&ltd,
+		&t.Packed,
+		&t.Salted,
+		&expiresAt,
+		&domain,
+		&types,
+		&pt,
+		&msgs,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, ErrNotFound
+	} else if err != nil {
+		return t, err
+	}
+
+	t.ExpiresAt = unixTime(expiresAt)
+
+	// -1 is no limit
+	t.Leaves, err = s.leaves(ctx, root, 0, -1)
+	if err != nil {
+		return t, err
+	}
+	if ltd.Valid {
+		if err := json.Unmarshal([]byte(ltd.String), &t.Ltd); err != nil {
+			return t, fmt.Errorf("decoding ltd: %w", err)
+		}
+	}
+	if types.Valid {
+		t.TypedData = &TypedData{PrimaryType: pt.String}
+		if err := json.Unmarshal([]byte(types.String), &t.TypedData.Types); err != nil {
+			return t, fmt.Errorf("decoding types: %w", err)
+		}
+		if err := unmarshalMessages([]byte(msgs.String), &t.TypedData.Messages); err != nil {
+			return t, fmt.Errorf("decoding messages: %w", err)
+		}
+		if err := json.Unmarshal([]byte(domain.String), &t.TypedData.Domain); err != nil {
+			return t, fmt.Errorf("decoding domain: %w", err)
+		}
+	}
+	return t, nil
+}
+
+func (s *SQLiteStore) ExtendExpiry(ctx context.Context, root []byte, expiresAt time.Time) error {
+	const q = `
+		UPDATE trees
+		SET expires_at = ?
+		WHERE root = ?
+		AND expires_at IS NOT NULL
+		AND (? IS NULL OR expires_at < ?)
+	`
+	exp := nullUnix(expiresAt)
+	if _, err := s.db.ExecContext(ctx, q, exp, root, exp, exp); err != nil {
+		return fmt.Errorf("extending expiry: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) TouchTree(ctx context.Context, root []byte) error {
+	const q = `
+		UPDATE trees
+		SET accessed_at = unixepoch()
+		WHERE root = ?
+		AND accessed_at IS NULL
+	`
+	if _, err := s.db.ExecContext(ctx, q, root); err != nil {
+		return fmt.Errorf("touching tree: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ExpiredTrees(ctx context.Context, now, unreadBefore time.Time, limit int) ([][]byte, error) {
+	const q = `
+		SELECT root FROM trees
+		WHERE expires_at < ?
+		UNION
+		SELECT root FROM trees
+		WHERE accessed_at IS NULL
+		AND unixepoch(inserted_at) < ?
+		LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, q, now.Unix(), nullUnix(unreadBefore), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roots [][]byte
+	for rows.Next() {
+		var rb []byte
+		if err := rows.Scan(&rb); err != nil {
+			return nil, err
+		}
+		roots = append(roots, rb)
+	}
+	return roots, rows.Err()
+}
+
+func (s *SQLiteStore) GetLeaves(ctx context.Context, root []byte, offset, limit int) (TreeRecord, int, error) {
+	const q = `
+		SELECT ltd, packed, salted, leaf_count, expires_at
+		FROM trees
+		WHERE root = ?
+	`
+	var (
+		t         = TreeRecord{Root: root}
+		ltd       sql.NullString
+		n         int
+		expiresAt sql.NullInt64
+	)
+	err := s.db.QueryRowContext(ctx, q, root).Scan(
+		&ltd,
+		&t.Packed,
+		&t.Salted,
+		&n,
+		&expiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, 0, ErrNotFound
+	} else if err != nil {
+		return t, 0, err
+	}
+	t.ExpiresAt = unixTime(expiresAt)
+	if ltd.Valid {
+		if err := json.Unmarshal([]byte(ltd.String), &t.Ltd); err != nil {
+			return t, 0, fmt.Errorf("decoding ltd: %w", err)
+		}
+	}
+
+	t.Leaves, err = s.leaves(ctx, root, offset, limit)
+	return t, n, err
+}
+
+func (s *SQLiteStore) leaves(ctx context.Context, root []byte, offset, limit int) ([][]byte, error) {
+	const q = `
+		SELECT leaf
+		FROM tree_leaves
+		WHERE root = ? AND idx >= ?
+		ORDER BY idx
+		LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, q, root, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leaves [][]byte
+	for rows.Next() {
+		var leaf []byte
+		if err := rows.Scan(&leaf); err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	return leaves, rows.Err()
+}
+
+func (s *SQLiteStore) RootsByProofHash(ctx context.Context, hash []byte) ([][]byte, error) {
+	const q = `
+		SELECT p.root
+		FROM proofs_hashes p
+		WHERE p.hash = ?
+		AND NOT EXISTS (
+			SELECT 1 FROM tree_owners o
+			WHERE o.root = p.root AND o.hidden
+		)
+		group by 1;
+	`
+	rows, err := s.db.QueryContext(ctx, q, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roots [][]byte
+	for rows.Next() {
+		var rb []byte
+		if err := rows.Scan(&rb); err != nil {
+			return nil, err
+		}
+		roots = append(roots, rb)
+	}
+	return roots, rows.Err()
+}
+
+func (s *SQLiteStore) InsertMetadata(ctx context.Context, root []byte, m MetadataRecord) (bool, error) {
+	const q = `
+		INSERT INTO tree_metadata(
+			root,
+			name,
+			description,
+			creator,
+			tags,
+			chain_id,
+			external_url,
+			edit_token_hash
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (root)
+		DO NOTHING
+	`
+	tags, err := json.Marshal(tagsOrEmpty(m.Tags))
+	if err != nil {
+		return false, fmt.Errorf("encoding tags: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx, q,
+		root,
+		m.Name,
+		m.Description,
+		creatorBytes(m.Creator),
+		string(tags),
+		int64(m.ChainID),
+		m.ExternalURL,
+		m.EditTokenHash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("inserting metadata: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *SQLiteStore) GetMetadata(ctx context.Context, root []byte) (MetadataRecord, error) {
+	const q = `
+		SELECT
+			name,
+			description,
+			creator,
+			tags,
+			chain_id,
+			external_url,
+			edit_token_hash
+		FROM tree_metadata
+		WHERE root = ?
+	`
+	var (
+		m       MetadataRecord
+		creator []byte
+		tags    string
+		chainID int64
+	)
+	err := s.db.QueryRowContext(ctx, q, root).Scan(
+		&m.Name,
+		&m.Description,
+		&creator,
+		&tags,
+		&chainID,
+		&m.ExternalURL,
+		&m.EditTokenHash,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return m, ErrNotFound
+	} else if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal([]byte(tags), &m.Tags); err != nil {
+		return m, fmt.Errorf("decoding tags: %w", err)
+	}
+	m.Creator = bytesCreator(creator)
+	m.ChainID = uint64(chainID)
+	return m, nil
+}
+
+func (s *SQLiteStore) UpdateMetadata(ctx context.Context, root []byte, m Metadata) error {
+	const q = `
+		UPDATE tree_metadata SET
+			name = ?,
+			description = ?,
+			creator = ?,
+			tags = ?,
+			chain_id = ?,
+			external_url = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE root = ?
+	`
+	tags, err := json.Marshal(tagsOrEmpty(m.Tags))
+	if err != nil {
+		return fmt.Errorf("encoding tags: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx, q,
+		m.Name,
+		m.Description,
+		creatorBytes(m.Creator),
+		string(tags),
+		int64(m.ChainID),
+		m.ExternalURL,
+		root,
+	)
+	if err != nil {
+		return fmt.Errorf("updating metadata: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) InsertOwner(ctx context.Context, root []byte, owner common.Address) (bool, error) {
+	const q = `
+		INSERT INTO tree_owners(root, owner)
+		VALUES (?, ?)
+		ON CONFLICT (root)
+		DO NOTHING
+	`
+	res, err := s.db.ExecContext(ctx, q, root, owner.Bytes())
+	if err != nil {
+		return false, fmt.Errorf("inserting owner: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *SQLiteStore) GetOwner(ctx context.Context, root []byte) (OwnerRecord, error) {
+	const q = `
+		SELECT owner, hidden, signed_at
+		FROM tree_owners
+		WHERE root = ?
+	`
+	var (
+		o     OwnerRecord
+		owner []byte
+	)
+	err := s.db.QueryRowContext(ctx, q, root).Scan(&owner, &o.Hidden, &o.SignedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return o, ErrNotFound
+	} else if err != nil {
+		return o, err
+	}
+	o.Owner = common.BytesToAddress(owner)
+	return o, nil
+}
+
+func (s *SQLiteStore) UpdateOwner(ctx context.Context, root []byte, hidden bool, signedAt int64) (bool, error) {
+	const q = `
+		UPDATE tree_owners SET
+			hidden = ?,
+			signed_at = ?
+		WHERE root = ?
+		AND signed_at < ?
+	`
+	res, err := s.db.ExecContext(ctx, q, hidden, signedAt, root, signedAt)
+	if err != nil {
+		return false, fmt.Errorf("updating owner: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *SQLiteStore) DeleteTree(ctx context.Context, root []byte) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, t := range treeTables {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+t+" WHERE root = ?", root); err != nil {
+			return fmt.Errorf("deleting from %s: %w", t, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,345 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrNotFound is returned by a [Store] when a tree does not exist.
+var ErrNotFound = errors.New("not found")
+
+// A tree as persisted by a [Store].
+type TreeRecord struct {
+	Root   []byte
+	Leaves [][]byte // unhashed
+	Ltd    []string
+	Packed bool
+	Salted bool
+
+	// Only set for trees built from EIP-712 messages
+	TypedData *TypedData
+
+	// Zero for trees that don't expire
+	ExpiresAt time.Time
+}
+
+// Returns nil for the zero time so it's stored as NULL
+// or omitted from JSON
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// A Store persists trees along with the hashes of
+// their proofs so that roots can be found by proof.
+type Store interface {
+	// Reports whether a tree with root has been inserted.
+	TreeExists(ctx context.Context, root []byte) (bool, error)
+
+	// Inserts the tree and its proof hashes.
+	// Inserting a tree that already exists is not an error
+	// and leaves the existing tree untouched.
+	InsertTree(ctx context.Context, t TreeRecord, proofHashes [][]byte) error
+
+	// Moves the expiry of the tree with root to expiresAt if
+	// the tree expires before then. A zero expiresAt removes
+	// the expiry. Trees that don't expire are left untouched.
+	ExtendExpiry(ctx context.Context, root []byte, expiresAt time.Time) error
+
+	// Records that the tree with root has been read.
+	// Only the first read is recorded.
+	TouchTree(ctx context.Context, root []byte) error
+
+	// Returns the roots of at most limit trees that expired
+	// before now. Unless unreadBefore is zero, trees that were
+	// created before unreadBefore and have never been read
+	// are also returned.
+	ExpiredTrees(ctx context.Context, now, unreadBefore time.Time, limit int) ([][]byte, error)
+
+	// Returns [ErrNotFound] if there is no tree with root.
+	GetTree(ctx context.Context, root []byte) (TreeRecord, error)
+
+	// Returns the tree with at most limit of its leaves
+	// starting at offset along with the number of leaves
+	// in the tree. Only the requested leaves are loaded and
+	// TypedData is not set. Returns [ErrNotFound] if there
+	// is no tree with root.
+	GetLeaves(ctx context.Context, root []byte, offset, limit int) (TreeRecord, int, error)
+
+	// Returns the roots of every tree with a proof that
+	// hashes to hash, excluding trees hidden by their owner.
+	// Returns an empty list if there are none.
+	RootsByProofHash(ctx context.Context, hash []byte) ([][]byte, error)
+
+	// Inserts metadata for the tree with root unless it
+	// already has metadata. Reports whether it was inserted.
+	InsertMetadata(ctx context.Context, root []byte, m MetadataRecord) (bool, error)
+
+	// Returns [ErrNotFound] if the tree has no metadata.
+	GetMetadata(ctx context.Context, root []byte) (MetadataRecord, error)
+
+	// Replaces the metadata of the tree with root.
+	// Returns [ErrNotFound] if the tree has no metadata.
+	UpdateMetadata(ctx context.Context, root []byte, m Metadata) error
+
+	// Records owner as the owner of the tree with root unless
+	// it already has an owner. Reports whether it was inserted.
+	InsertOwner(ctx context.Context, root []byte, owner common.Address) (bool, error)
+
+	// Returns [ErrNotFound] if the tree has no owner.
+	GetOwner(ctx context.Context, root []byte) (OwnerRecord, error)
+
+	// Sets whether the tree with root is hidden and records
+	// signedAt as the time of the owner's last request. Nothing
+	// is updated unless signedAt is after the previous request.
+	// Reports whether the owner was updated.
+	UpdateOwner(ctx context.Context, root []byte, hidden bool, signedAt int64) (bool, error)
+
+	// Deletes the tree with root along with its proof
+	// hashes, metadata and owner. Deleting a tree that
+	// does not exist is not an error.
+	DeleteTree(ctx context.Context, root []byte) error
+}
+
+// MemStore is an in-memory [Store]. It is
+// intended for tests and local development.
+type MemStore struct {
+	mu       sync.RWMutex
+	trees    map[common.Hash]TreeRecord
+	proofs   map[common.Hash][][]byte
+	metadata map[common.Hash]MetadataRecord
+	owners   map[common.Hash]OwnerRecord
+
+	// when each tree was inserted and whether it has been read
+	inserted map[common.Hash]time.Time
+	read     map[common.Hash]bool
+}
+
+func NewMemStore() *MemStore {
+	return &MemStore{
+		trees:    map[common.Hash]TreeRecord{},
+		proofs:   map[common.Hash][][]byte{},
+		metadata: map[common.Hash]MetadataRecord{},
+		owners:   map[common.Hash]OwnerRecord{},
+		inserted: map[common.Hash]time.Time{},
+		read:     map[common.Hash]bool{},
+	}
+}
+
+func (m *MemStore) TreeExists(ctx context.Context, root []byte) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.trees[common.BytesToHash(root)]
+	return ok, nil
+}
+
+func (m *MemStore) InsertTree(ctx context.Context, t TreeRecord, proofHashes [][]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	root := common.BytesToHash(t.Root)
+	if _, ok := m.trees[root]; ok {
+		return nil
+	}
+	m.trees[root] = t
+	m.inserted[root] = time.Now()
+	for _, h := range proofHashes {
+		ph := common.BytesToHash(h)
+		m.proofs[ph] = append(m.proofs[ph], t.Root)
+	}
+	return nil
+}
+
+func (m *MemStore) ExtendExpiry(ctx context.Context, root []byte, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := common.BytesToHash(root)
+	t, ok := m.trees[r]
+	if !ok || t.ExpiresAt.IsZero() {
+		return nil
+	}
+	if expiresAt.IsZero() || expiresAt.After(t.ExpiresAt) {
+		t.ExpiresAt = expiresAt
+		m.trees[r] = t
+	}
+	return nil
+}
+
+func (m *MemStore) TouchTree(ctx context.Context, root []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := common.BytesToHash(root)
+	if _, ok := m.trees[r]; ok {
+		m.read[r] = true
+	}
+	return nil
+}
+
+func (m *MemStore) ExpiredTrees(ctx context.Context, now, unreadBefore time.Time, limit int) ([][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var roots [][]byte
+	for r, t := range m.trees {
+		if len(roots) == limit {
+			break
+		}
+		expired := !t.ExpiresAt.IsZero() && t.ExpiresAt.Before(now)
+		unread := !unreadBefore.IsZero() && !m.read[r] && m.inserted[r].Before(unreadBefore)
+		if expired || unread {
+			roots = append(roots, t.Root)
+		}
+	}
+	return roots, nil
+}
+
+func (m *MemStore) GetTree(ctx context.Context, root []byte) (TreeRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.trees[common.BytesToHash(root)]
+	if !ok {
+		return TreeRecord{}, ErrNotFound
+	}
+	return t, nil
+}
+
+func (m *MemStore) GetLeaves(ctx context.Context, root []byte, offset, limit int) (TreeRecord, int, error) {
+	t, err := m.GetTree(ctx, root)
+	if err != nil {
+		return t, 0, err
+	}
+	n := len(t.Leaves)
+	start, end := offset, n
+	if start > n {
+		start = n
+	}
+	// compared before adding so huge offsets can't overflow
+	if limit < n-start {
+		end = start + limit
+	}
+	t.Leaves = t.Leaves[start:end]
+	t.TypedData = nil
+	return t, n, nil
+}
+
+func (m *MemStore) RootsByProofHash(ctx context.Context, hash []byte) ([][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var roots [][]byte
+	for _, r := range m.proofs[common.BytesToHash(hash)] {
+		if m.owners[common.BytesToHash(r)].Hidden {
+			continue
+		}
+		// match the postgres store which groups by root
+		dup := false
+		for _, seen := range roots {
+			if bytes.Equal(seen, r) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			roots = append(roots, r)
+		}
+	}
+	return roots, nil
+}
+
+func (m *MemStore) InsertMetadata(ctx context.Context, root []byte, md MetadataRecord) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := common.BytesToHash(root)
+	if _, ok := m.metadata[r]; ok {
+		return false, nil
+	}
+	m.metadata[r] = md
+	return true, nil
+}
+
+func (m *MemStore) GetMetadata(ctx context.Context, root []byte) (MetadataRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	md, ok := m.metadata[common.BytesToHash(root)]
+	if !ok {
+		return MetadataRecord{}, ErrNotFound
+	}
+	return md, nil
+}
+
+func (m *MemStore) UpdateMetadata(ctx context.Context, root []byte, md Metadata) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := common.BytesToHash(root)
+	rec, ok := m.metadata[r]
+	if !ok {
+		return ErrNotFound
+	}
+	rec.Metadata = md
+	m.metadata[r] = rec
+	return nil
+}
+
+func (m *MemStore) InsertOwner(ctx context.Context, root []byte, owner common.Address) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := common.BytesToHash(root)
+	if _, ok := m.owners[r]; ok {
+		return false, nil
+	}
+	m.owners[r] = OwnerRecord{Owner: owner}
+	return true, nil
+}
+
+func (m *MemStore) GetOwner(ctx context.Context, root []byte) (OwnerRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	o, ok := m.owners[common.BytesToHash(root)]
+	if !ok {
+		return OwnerRecord{}, ErrNotFound
+	}
+	return o, nil
+}
+
+func (m *MemStore) UpdateOwner(ctx context.Context, root []byte, hidden bool, signedAt int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := common.BytesToHash(root)
+	o, ok := m.owners[r]
+	if !ok || signedAt <= o.SignedAt {
+		return false, nil
+	}
+	o.Hidden = hidden
+	o.SignedAt = signedAt
+	m.owners[r] = o
+	return true, nil
+}
+
+func (m *MemStore) DeleteTree(ctx context.Context, root []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := common.BytesToHash(root)
+	for h, roots := range m.proofs {
+		kept := roots[:0]
+		for _, rb := range roots {
+			if !bytes.Equal(rb, root) {
+				kept = append(kept, rb)
+			}
+		}
+		if len(kept) == 0 {
+			delete(m.proofs, h)
+		} else {
+			m.proofs[h] = kept
+		}
+	}
+	delete(m.trees, r)
+	delete(m.metadata, r)
+	delete(m.owners, r)
+	delete(m.inserted, r)
+	delete(m.read, r)
+	return nil
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/contextwtf/lanyard/api/migrations"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	_ "modernc.org/sqlite"
+)
+
+func do(t *testing.T, h http.Handler, method, path string, body, resp any) int {
+	t.Helper()
+	var b bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&b).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, &b))
+	if resp != nil && w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code
+}
+
+// Opens an empty store of each kind for a test
+var testStores = []struct {
+	name string
+	open func(t *testing.T) Store
+}{
+	{"mem", func(*testing.T) Store { return NewMemStore() }},
+	{"sqlite", openSQLiteStore},
+}
+
+func openSQLiteStore(t *testing.T) Store {
+	t.Helper()
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+
+	// running twice only applies new migrations
+	for i := 0; i < 2; i++ {
+		if err := migrations.RunSQLite(ctx, db, migrations.SQLite); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewSQLiteStore(db)
+}
+
+// Creates a tree through h
+func createTree(t *testing.T, h http.Handler, req createTreeReq) createTreeResp {
+	t.Helper()
+	var created createTreeResp
+	if code := do(t, h, http.MethodPost, "/api/v1/tree", req, &created); code != http.StatusOK {
+		t.Fatalf("create: expected: %d got: %d", http.StatusOK, code)
+	}
+	return created
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if err := migrations.RunSQLite(ctx, db, migrations.SQLite[:1]); err != nil {
+		t.Fatal(err)
+	}
+	const q = `
+		INSERT INTO trees (root, unhashed_leaves, packed)
+		VALUES (x'01', '["0x0a","0x0b0c"]', true)
+	`
+	if _, err := db.ExecContext(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrations.RunSQLite(ctx, db, migrations.SQLite); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, n, err := NewSQLiteStore(db).GetLeaves(ctx, []byte{0x01}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("leaf count: expected: 2 got: %d", n)
+	}
+	if len(rec.Leaves) != 1 || !bytes.Equal(rec.Leaves[0], []byte{0x0b, 0x0c}) {
+		t.Errorf("expected: [0x0b0c] got: %x", rec.Leaves)
+	}
+
+	// trees are read from tree_leaves once unhashed_leaves is dropped
+	rec, err = NewSQLiteStore(db).GetTree(ctx, []byte{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Leaves) != 2 || !bytes.Equal(rec.Leaves[0], []byte{0x0a}) || !bytes.Equal(rec.Leaves[1], []byte{0x0b, 0x0c}) {
+		t.Errorf("expected: [0x0a 0x0b0c] got: %x", rec.Leaves)
+	}
+}
+
+var storeLeaves = []string{
+	"0x0000000000000000000000000000000000000001",
+	"0x0000000000000000000000000000000000000002",
+	"0x0000000000000000000000000000000000000003",
+}
+
+func TestStoreGetTree(t *testing.T) {
+	cases := []struct {
+		req    createTreeReq
+		ltd    []string
+		packed bool
+	}{
+		{createTreeReq{Leaves: storeLeaves}, nil, false},
+		{createTreeReq{Leaves: storeLeaves[1:], Ltd: []string{"address"}, Packed: true}, []string{"address"}, true},
+	}
+	for _, ts := range testStores {
+		t.Run(ts.name, func(t *testing.T) {
+			h := New(ts.open(t)).Handler("test", "")
+			for i, c := range cases {
+				created := createTree(t, h, c.req)
+				// creating the tree again keeps the first tree
+				createTree(t, h, createTreeReq{Leaves: c.req.Leaves})
+
+				var tree getTreeResp
+				if code := do(t, h, http.MethodGet, "/api/v1/tree?root="+created.MerkleRoot, nil, &tree); code != http.StatusOK {
+					t.Fatalf("%d: expected: %d got: %d", i, http.StatusOK, code)
+				}
+				if tree.LeafCount != len(c.req.Leaves) {
+					t.Errorf("%d: expected: %d got: %d", i, len(c.req.Leaves), tree.LeafCount)
+				}
+				if strings.Join(tree.Ltd, ",") != strings.Join(c.ltd, ",") || tree.Packed != c.packed {
+					t.Errorf("%d: expected: %v %t got: %v %t", i, c.ltd, c.packed, tree.Ltd, tree.Packed)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreLeafPages(t *testing.T) {
+	cases := []struct {
+		limit string
+		pages int
+	}{
+		{"1", 3},
+		{"2", 2},
+		{"3", 1},
+		{"10", 1},
+	}
+	for _, ts := range testStores {
+		t.Run(ts.name, func(t *testing.T) {
+			var (
+				h       = New(ts.open(t)).Handler("test", "")
+				created = createTree(t, h, createTreeReq{Leaves: storeLeaves})
+			)
+			for _, c := range cases {
+				var (
+					paged  []hexutil.Bytes
+					pages  int
+					cursor = "0"
+				)
+				for cursor != "" {
+					var page getTreeResp
+					code := do(t, h, http.MethodGet, "/api/v1/tree?root="+created.MerkleRoot+"&limit="+c.limit+"&cursor="+cursor, nil, &page)
+					if code != http.StatusOK {
+						t.Fatalf("limit %s: expected: %d got: %d", c.limit, http.StatusOK, code)
+					}
+					if page.LeafCount != len(storeLeaves) {
+						t.Errorf("limit %s: expected: %d got: %d", c.limit, len(storeLeaves), page.LeafCount)
+					}
+					paged = append(paged, page.UnhashedLeaves...)
+					cursor = page.NextCursor
+					pages++
+				}
+				if pages != c.pages {
+					t.Errorf("limit %s: expected: %d pages got: %d", c.limit, c.pages, pages)
+				}
+				if len(paged) != len(storeLeaves) {
+					t.Fatalf("limit %s: expected: %d got: %d", c.limit, len(storeLeaves), len(paged))
+				}
+				for i := range storeLeaves {
+					if paged[i].String() != storeLeaves[i] {
+						t.Errorf("limit %s: expected: %s got: %s", c.limit, storeLeaves[i], paged[i])
+					}
+				}
+			}
+
+			var page getTreeResp
+			code := do(t, h, http.MethodGet, "/api/v1/tree?root="+created.MerkleRoot+"&cursor=9223372036854775807", nil, &page)
+			if code != http.StatusOK || len(page.UnhashedLeaves) != 0 || page.NextCursor != "" {
+				t.Errorf("past the end: expected: %d and no leaves got: %d %v", http.StatusOK, code, page.UnhashedLeaves)
+			}
+		})
+	}
+}
+
+func TestStoreRoots(t *testing.T) {
+	for _, ts := range testStores {
+		t.Run(ts.name, func(t *testing.T) {
+			var (
+				h       = New(ts.open(t)).Handler("test", "")
+				created = createTree(t, h, createTreeReq{Leaves: storeLeaves})
+				proof   getProofResp
+			)
+			code := do(t, h, http.MethodGet, "/api/v1/proof?root="+created.MerkleRoot+"&unhashedLeaf="+storeLeaves[2], nil, &proof)
+			if code != http.StatusOK {
+				t.Fatalf("expected: %d got: %d", http.StatusOK, code)
+			}
+			var ps []string
+			for _, p := range proof.Proof {
+				ps = append(ps, p.String())
+			}
+			var roots struct {
+				Roots []hexutil.Bytes `json:"roots"`
+			}
+			if code := do(t, h, http.MethodGet, "/api/v1/roots?proof="+strings.Join(ps, ","), nil, &roots); code != http.StatusOK {
+				t.Fatalf("expected: %d got: %d", http.StatusOK, code)
+			}
+			if len(roots.Roots) != 1 || roots.Roots[0].String() != created.MerkleRoot {
+				t.Errorf("expected: [%s] got: %v", created.MerkleRoot, roots.Roots)
+			}
+		})
+	}
+}
+
+func TestStoreNotFound(t *testing.T) {
+	missing := "0x" + strings.Repeat("ab", 32)
+	cases := []string{
+		"/api/v1/tree?root=" + missing,
+		"/api/v1/proof?root=" + missing + "&unhashedLeaf=" + storeLeaves[0],
+		"/api/v1/roots?proof=" + missing,
+	}
+	for _, ts := range testStores {
+		t.Run(ts.name, func(t *testing.T) {
+			h := New(ts.open(t)).Handler("test", "")
+			createTree(t, h, createTreeReq{Leaves: storeLeaves})
+			for _, path := range cases {
+				if code := do(t, h, http.MethodGet, path, nil, nil); code != http.StatusNotFound {
+					t.Errorf("%s: expected: %d got: %d", path, http.StatusNotFound, code)
+				}
+			}
+		})
+	}
+}
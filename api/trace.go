@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/contextwtf/lanyard/merkle"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type traceStep struct {
+	Node         hexutil.Bytes `json:"node"`
+	Sibling      hexutil.Bytes `json:"sibling"`
+	SiblingFirst bool          `json:"siblingFirst"`
+	Output       hexutil.Bytes `json:"output"`
+}
+
+type getTraceResp struct {
+	Valid        bool          `json:"valid"`
+	LeafHash     hexutil.Bytes `json:"leafHash"`
+	ComputedRoot hexutil.Bytes `json:"computedRoot"`
+	Steps        []traceStep   `json:"steps"`
+
+	// Only set if the tree has been published
+	TreeFound     bool            `json:"treeFound"`
+	LeafInTree    bool            `json:"leafInTree"`
+	ExpectedProof []hexutil.Bytes `json:"expectedProof,omitempty"`
+	DivergesAt    *int            `json:"divergesAt,omitempty"`
+	Note          string          `json:"note,omitempty"`
+}
+
+// Returns the index of the first proof element that
+// differs from the expected proof or -1 if they are equal.
+func firstDivergence(proof, expected [][]byte) int {
+	for i := range proof {
+		if i >= len(expected) || !bytes.Equal(proof[i], expected[i]) {
+			return i
+		}
+	}
+	if len(proof) != len(expected) {
+		return len(proof)
+	}
+	return -1
+}
+
+func (s *Server) GetTrace(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx   = r.Context()
+		root  = r.URL.Query().Get("root")
+		leaf  = common.FromHex(r.URL.Query().Get("unhashedLeaf"))
+		salt  = common.FromHex(r.URL.Query().Get("salt"))
+		proof = r.URL.Query().Get("proof")
+		pb    = [][]byte{}
+	)
+	if root == "" {
+		s.sendFieldError(r, w, nil, "root", "missing root")
+		return
+	}
+	if len(leaf) == 0 {
+		s.sendFieldError(r, w, nil, "unhashedLeaf", "missing leaf")
+		return
+	}
+	if proof != "" {
+		for _, p := range strings.Split(proof, ",") {
+			b, err := hexutil.Decode(p)
+			if err != nil {
+				s.sendFieldError(r, w, nil, "proof", "malformed list of proofs")
+				return
+			}
+			pb = append(pb, b)
+		}
+	}
+
+	// leaves of salted trees are keccak256(salt || unhashedLeaf)
+	if len(salt) > 0 {
+		leaf = append(salt, leaf...)
+	}
+
+	var (
+		rb   = common.HexToHash(root)
+		pt   = merkle.Trace(rb.Bytes(), pb, leaf)
+		resp = getTraceResp{
+			Valid:        pt.Valid,
+			LeafHash:     pt.LeafHash,
+			ComputedRoot: pt.Root,
+			Steps:        make([]traceStep, 0, len(pt.Steps)),
+		}
+	)
+	for _, st := range pt.Steps {
+		resp.Steps = append(resp.Steps, traceStep{
+			Node:         st.Node,
+			Sibling:      st.Sibling,
+			SiblingFirst: st.SiblingFirst,
+			Output:       st.Output,
+		})
+	}
+
+	ct, err := s.getCachedTree(ctx, rb)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		resp.Note = "tree not found for root"
+	case err != nil:
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting tree")
+		return
+	default:
+		resp.TreeFound = true
+		if ct.r.Salted && len(salt) == 0 {
+			resp.Note = "tree is salted, set salt to the salt returned with the proof"
+			break
+		}
+		idx := ct.t.Index(leaf)
+		if idx == -1 {
+			resp.Note = "leaf not found in tree"
+			break
+		}
+		resp.LeafInTree = true
+		expected := ct.t.Proof(idx)
+		for _, p := range expected {
+			resp.ExpectedProof = append(resp.ExpectedProof, p)
+		}
+		if d := firstDivergence(pb, expected); d != -1 {
+			resp.DivergesAt = &d
+			resp.Note = "proof differs from the expected proof at divergesAt"
+		}
+	}
+
+	s.sendJSON(r, w, resp)
+}
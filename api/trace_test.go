@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFirstDivergence(t *testing.T) {
+	var (
+		a = []byte{0x01}
+		b = []byte{0x02}
+		c = []byte{0x03}
+	)
+	cases := []struct {
+		proof, expected [][]byte
+		want            int
+	}{
+		{[][]byte{a, b}, [][]byte{a, b}, -1},
+		{[][]byte{a, c}, [][]byte{a, b}, 1},
+		{[][]byte{a}, [][]byte{a, b}, 1},
+		{[][]byte{a, b, c}, [][]byte{a, b}, 2},
+		{[][]byte{}, [][]byte{a}, 0},
+	}
+
+	for _, c := range cases {
+		if got := firstDivergence(c.proof, c.expected); got != c.want {
+			t.Errorf("expected: %d got: %d", c.want, got)
+		}
+	}
+}
+
+func TestTraceSalted(t *testing.T) {
+	h := New(NewMemStore()).Handler("test", "")
+
+	var tree createTreeResp
+	req := createTreeReq{Leaves: []string{"0x01", "0x02", "0x03"}, Salted: true}
+	if code := do(t, h, http.MethodPost, "/api/v1/tree", req, &tree); code != http.StatusOK {
+		t.Fatalf("expected: %d got: %d", http.StatusOK, code)
+	}
+	var pr getProofResp
+	if code := do(t, h, http.MethodGet, "/api/v1/proof?root="+tree.MerkleRoot+"&unhashedLeaf=0x02", nil, &pr); code != http.StatusOK {
+		t.Fatalf("expected: %d got: %d", http.StatusOK, code)
+	}
+	var proof []string
+	for _, p := range pr.Proof {
+		proof = append(proof, p.String())
+	}
+
+	cases := []struct {
+		salt        string
+		valid, leaf bool
+	}{
+		{pr.Salt.String(), true, true},
+		{"", false, false},
+	}
+	for _, c := range cases {
+		var resp getTraceResp
+		path := "/api/v1/proof/trace?root=" + tree.MerkleRoot + "&unhashedLeaf=0x02&proof=" + strings.Join(proof, ",")
+		if c.salt != "" {
+			path += "&salt=" + c.salt
+		}
+		if code := do(t, h, http.MethodGet, path, nil, &resp); code != http.StatusOK {
+			t.Fatalf("expected: %d got: %d", http.StatusOK, code)
+		}
+		if resp.Valid != c.valid || resp.LeafInTree != c.leaf {
+			t.Errorf("salt %q: expected: %t %t got: %t %t", c.salt, c.valid, c.leaf, resp.Valid, resp.LeafInTree)
+		}
+	}
+}
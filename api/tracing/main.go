@@ -2,10 +2,13 @@ package tracing
 
 import (
 	"context"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v4"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/propagation"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -25,6 +28,70 @@ func getQueryName(query string) (name string) {
 	return name
 }
 
+// A Span is a Datadog span unless [UseOpenTelemetry]
+// has been called, in which case it is an OpenTelemetry
+// span. Tags use the Datadog names from the ext package.
+type Span interface {
+	SetTag(key string, value any)
+	Finish()
+	FinishAt(t time.Time)
+
+	// Adds the trace id to log lines
+	LogContext(c zerolog.Context) zerolog.Context
+}
+
+type spanConfig struct {
+	start time.Time
+	tags  []tag
+}
+
+type tag struct {
+	key   string
+	value any
+}
+
+type SpanOption func(*spanConfig)
+
+func StartTime(t time.Time) SpanOption {
+	return func(c *spanConfig) { c.start = t }
+}
+
+func Tag(key string, value any) SpanOption {
+	return func(c *spanConfig) { c.tags = append(c.tags, tag{key, value}) }
+}
+
+// Starts a span that is a child of the span in ctx, if
+// there is one, and returns a context containing it.
+func StartSpan(ctx context.Context, name string, opts ...SpanOption) (Span, context.Context) {
+	var c spanConfig
+	for _, o := range opts {
+		o(&c)
+	}
+	if otelTracer != nil {
+		return startOTelSpan(ctx, name, c)
+	}
+
+	var ddopts []tracer.StartSpanOption
+	if !c.start.IsZero() {
+		ddopts = append(ddopts, tracer.StartTime(c.start))
+	}
+	for _, t := range c.tags {
+		ddopts = append(ddopts, tracer.Tag(t.key, t.value))
+	}
+	span, ctx := SpanFromContext(ctx, name, ddopts...)
+	return ddSpan{span}, ctx
+}
+
+// Returns a context containing the remote parent from
+// the traceparent header when using OpenTelemetry.
+// Datadog traces start at this service.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	if otelTracer == nil {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.HeaderCarrier(h))
+}
+
 func SpanFromContext(ctx context.Context, name string, opts ...tracer.StartSpanOption) (ddtrace.Span, context.Context) {
 	parent, ok := tracer.SpanFromContext(ctx)
 	if !ok {
@@ -41,6 +108,26 @@ func SpanFromContext(ctx context.Context, name string, opts ...tracer.StartSpanO
 	return span, tracer.ContextWithSpan(ctx, span)
 }
 
+type ddSpan struct {
+	ddtrace.Span
+}
+
+func (s ddSpan) Finish() {
+	s.Span.Finish()
+}
+
+func (s ddSpan) FinishAt(t time.Time) {
+	s.Span.Finish(tracer.FinishTime(t))
+}
+
+func (s ddSpan) LogContext(c zerolog.Context) zerolog.Context {
+	// spans from the noop tracer have no id
+	if id := s.Context().TraceID(); id != 0 {
+		return c.Uint64("dd.trace_id", id)
+	}
+	return c
+}
+
 type DBTracer struct {
 	serviceName string
 }
@@ -57,16 +144,13 @@ func (l *DBTracer) Log(ctx context.Context, level pgx.LogLevel, msg string, data
 	if timeOk && queryOk {
 		end := time.Now()
 		start := end.Add(-t)
-		startTime := tracer.StartTime(start)
-
-		opts := []ddtrace.StartSpanOption{
-			tracer.SpanType(ext.SpanTypeSQL),
-			tracer.ResourceName(string(q)),
-			tracer.ServiceName(l.serviceName),
-			startTime,
-		}
 
-		span, _ := SpanFromContext(ctx, getQueryName(q), opts...)
-		span.Finish(tracer.FinishTime(end))
+		span, _ := StartSpan(ctx, getQueryName(q),
+			StartTime(start),
+			Tag(ext.SpanType, ext.SpanTypeSQL),
+			Tag(ext.ResourceName, q),
+			Tag(ext.ServiceName, l.serviceName),
+		)
+		span.FinishAt(end)
 	}
 }
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+var (
+	otelTracer trace.Tracer
+	propagator = propagation.TraceContext{}
+)
+
+// Sends spans to tp instead of Datadog and continues
+// traces from W3C traceparent headers. It must be
+// called before the server handles any requests.
+// Providers that batch spans must be shut down on
+// exit to send the spans that are still buffered.
+func UseOpenTelemetry(tp trace.TracerProvider) {
+	otelTracer = tp.Tracer("github.com/contextwtf/lanyard")
+}
+
+func startOTelSpan(ctx context.Context, name string, c spanConfig) (Span, context.Context) {
+	opts := []trace.SpanStartOption{}
+	if !c.start.IsZero() {
+		opts = append(opts, trace.WithTimestamp(c.start))
+	}
+	ctx, span := otelTracer.Start(ctx, name, opts...)
+	s := &otelSpan{Span: span}
+	for _, t := range c.tags {
+		s.SetTag(t.key, t.value)
+	}
+	return s, ctx
+}
+
+type otelSpan struct {
+	trace.Span
+	sql bool
+}
+
+// Translates the Datadog tags used by this
+// package to OpenTelemetry conventions.
+func (s *otelSpan) SetTag(key string, value any) {
+	switch key {
+	case ext.ResourceName:
+		if !s.sql {
+			s.SetName(fmt.Sprint(value))
+			return
+		}
+		key = "db.statement"
+	case ext.ServiceName:
+		key = "peer.service"
+	case ext.SpanType:
+		if value == ext.SpanTypeSQL {
+			s.sql = true
+			s.SetAttributes(attribute.String("db.system", "postgresql"))
+		}
+	case ext.HTTPCode:
+		if c, ok := value.(int); ok && c >= 500 {
+			s.SetStatus(codes.Error, "")
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		s.SetAttributes(attribute.String(key, v))
+	case int:
+		s.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.SetAttributes(attribute.Int64(key, v))
+	case float64:
+		s.SetAttributes(attribute.Float64(key, v))
+	case bool:
+		s.SetAttributes(attribute.Bool(key, v))
+	default:
+		s.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+func (s *otelSpan) Finish() {
+	s.End()
+}
+
+func (s *otelSpan) FinishAt(t time.Time) {
+	s.End(trace.WithTimestamp(t))
+}
+
+func (s *otelSpan) LogContext(c zerolog.Context) zerolog.Context {
+	sc := s.SpanContext()
+	if !sc.IsValid() {
+		return c
+	}
+	return c.Str("trace_id", sc.TraceID().String()).
+		Str("span_id", sc.SpanID().String())
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+func TestOpenTelemetry(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	UseOpenTelemetry(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	defer func() { otelTracer = nil }()
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	h := http.Header{}
+	h.Set("traceparent", "00-"+traceID+"-"+spanID+"-01")
+	ctx := Extract(context.Background(), h)
+
+	span, ctx := StartSpan(ctx, "http.request")
+	span.SetTag(ext.ResourceName, "/api/v1/tree")
+	span.SetTag(ext.HTTPCode, 200)
+
+	start := time.Now()
+	NewDBTracer("db").Log(ctx, 0, "", map[string]any{
+		"time": time.Millisecond,
+		"sql":  "SELECT 1",
+	})
+	span.Finish()
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected: 2 spans got: %d", len(spans))
+	}
+	db, req := spans[0], spans[1]
+
+	if got := req.Name(); got != "/api/v1/tree" {
+		t.Errorf("expected: /api/v1/tree got: %s", got)
+	}
+	if got := req.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("expected: %s got: %s", traceID, got)
+	}
+	if got := req.Parent().SpanID().String(); got != spanID {
+		t.Errorf("expected: %s got: %s", spanID, got)
+	}
+	if got := db.Parent().SpanID(); got != req.SpanContext().SpanID() {
+		t.Errorf("expected: %s got: %s", req.SpanContext().SpanID(), got)
+	}
+	if !db.StartTime().Before(start) {
+		t.Errorf("expected db span to start before %s got: %s", start, db.StartTime())
+	}
+
+	attrs := map[attribute.Key]string{}
+	for _, a := range db.Attributes() {
+		attrs[a.Key] = a.Value.Emit()
+	}
+	for k, v := range map[attribute.Key]string{
+		"db.statement": "SELECT 1",
+		"db.system":    "postgresql",
+		"peer.service": "db",
+	} {
+		if attrs[k] != v {
+			t.Errorf("expected %s: %s got: %s", k, v, attrs[k])
+		}
+	}
+}
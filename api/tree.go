@@ -1,18 +1,26 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/contextwtf/lanyard/merkle"
+	"github.com/contextwtf/lanyard/merkle/format"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/jackc/pgx/v4"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
 func (s *Server) TreeHandler(w http.ResponseWriter, r *http.Request) {
@@ -24,7 +32,7 @@ func (s *Server) TreeHandler(w http.ResponseWriter, r *http.Request) {
 		s.GetTree(w, r)
 		return
 	default:
-		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+		s.sendJSONError(r, w, nil, http.StatusMethodNotAllowed, "unsupported method")
 		return
 	}
 }
@@ -86,13 +94,160 @@ func hashProof(p [][]byte) []byte {
 }
 
 type createTreeReq struct {
-	Leaves []string `json:"unhashedLeaves"`
-	Ltd    []string `json:"leafTypeDescriptor"`
-	Packed bool     `json:"packedEncoding"`
+	Leaves []string   `json:"unhashedLeaves"`
+	Ltd    []string   `json:"leafTypeDescriptor"`
+	Packed bool       `json:"packedEncoding"`
+	EIP712 *TypedData `json:"eip712"`
+	Salted bool       `json:"salted"`
+
+	Metadata *Metadata `json:"metadata"`
+
+	// Seconds until the tree is deleted. Zero
+	// for trees that should be kept forever.
+	TTL int64 `json:"ttl"`
+
+	// personal_sign (EIP-191) signature of the 32 byte
+	// root. The signer is recorded as the owner of the tree.
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// Length of the random salt prepended to each leaf of a salted tree
+const saltLen = 32
+
+// Prepends a random salt to each leaf so that the leaf
+// hashes, keccak256(salt || leaf), cannot be brute-forced
+// back to the unhashed leaves. Proofs are still looked up
+// by unhashed leaf and address, so the API reveals whether
+// a guessed leaf is in the tree.
+func saltLeaves(leaves [][]byte) ([][]byte, error) {
+	salted := make([][]byte, 0, len(leaves))
+	for _, l := range leaves {
+		sl := make([]byte, saltLen, saltLen+len(l))
+		if _, err := rand.Read(sl); err != nil {
+			return nil, err
+		}
+		salted = append(salted, append(sl, l...))
+	}
+	return salted, nil
+}
+
+// Splits a stored leaf into its salt and the leaf
+// that was provided when the tree was created.
+func splitSalt(leaf []byte, salted bool) (salt, payload []byte) {
+	if !salted || len(leaf) < saltLen {
+		return nil, leaf
+	}
+	return leaf[:saltLen], leaf[saltLen:]
+}
+
+// EIP-712 struct definitions and the messages that
+// make up the leaves of a tree. Each leaf is the
+// EIP-712 digest of the corresponding message.
+type TypedData struct {
+	Domain      apitypes.TypedDataDomain    `json:"domain"`
+	Types       apitypes.Types              `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Messages    []apitypes.TypedDataMessage `json:"messages"`
+}
+
+// Decodes integers in messages as decimal strings since
+// float64 loses precision for uint256 values and
+// go-ethereum accepts integers as strings.
+func (td *TypedData) UnmarshalJSON(b []byte) error {
+	type typedData TypedData
+	var v typedData
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	for _, m := range v.Messages {
+		numbersToStrings(map[string]any(m))
+	}
+	*td = TypedData(v)
+	return nil
+}
+
+// Like [TypedData.UnmarshalJSON] for messages on their own
+func unmarshalMessages(b []byte, msgs *[]apitypes.TypedDataMessage) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(msgs); err != nil {
+		return err
+	}
+	for _, m := range *msgs {
+		numbersToStrings(map[string]any(m))
+	}
+	return nil
+}
+
+// Replaces integer json.Numbers in v with strings
+// and other numbers with float64.
+func numbersToStrings(v any) any {
+	switch x := v.(type) {
+	case json.Number:
+		if _, ok := new(big.Int).SetString(x.String(), 10); ok {
+			return x.String()
+		}
+		f, _ := x.Float64()
+		return f
+	case map[string]any:
+		for k, e := range x {
+			x[k] = numbersToStrings(e)
+		}
+	case []any:
+		for i, e := range x {
+			x[i] = numbersToStrings(e)
+		}
+	}
+	return v
+}
+
+// Leaf type descriptor for trees whose leaves are 32 byte hashes
+// such as EIP-712 struct hashes or imported OpenZeppelin leaves
+var bytes32Ltd = []string{"bytes32"}
+
+func (td *TypedData) leaves() ([][]byte, error) {
+	var (
+		enc    = merkle.EIP712Encoder{Domain: td.Domain, Types: td.Types, PrimaryType: td.PrimaryType}
+		leaves = make([][]byte, 0, len(td.Messages))
+	)
+	for i, m := range td.Messages {
+		l, err := enc.EncodeLeaf(m)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		leaves = append(leaves, l)
+	}
+	return leaves, nil
+}
+
+// Returns the first address field of the i-th message
+// or an empty slice if the primary type has no address field.
+func (td *TypedData) addr(i int) []byte {
+	for _, f := range td.Types[td.PrimaryType] {
+		if f.Type != "address" {
+			continue
+		}
+		a, ok := td.Messages[i][f.Name].(string)
+		if !ok || !common.IsHexAddress(a) {
+			return []byte{}
+		}
+		return common.HexToAddress(a).Bytes()
+	}
+	return []byte{}
 }
 
 type createTreeResp struct {
-	MerkleRoot string `json:"merkleRoot"`
+	MerkleRoot string           `json:"merkleRoot"`
+	Warnings   []merkle.Warning `json:"warnings,omitempty"`
+
+	// Returned once when metadata is stored and
+	// required to edit it, see [Server.UpdateMetadata]
+	EditToken string `json:"editToken,omitempty"`
+
+	// Set when the request was signed, see [Server.OwnerAction]
+	Owner *common.Address `json:"owner,omitempty"`
 }
 
 func (s *Server) CreateTree(w http.ResponseWriter, r *http.Request) {
@@ -101,111 +256,208 @@ func (s *Server) CreateTree(w http.ResponseWriter, r *http.Request) {
 		ctx = r.Context()
 	)
 	defer r.Body.Close()
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.sendJSONError(r, w, err, http.StatusBadRequest, "invalid request body")
-		return
-	}
-	switch len(req.Leaves) {
-	case 0:
-		s.sendJSONError(r, w, nil, http.StatusBadRequest, "No leaves provided")
-		return
-	case 1:
-		s.sendJSONError(r, w, nil, http.StatusBadRequest, "You must provide at least two values")
-		return
+	switch mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt {
+	case mediaTypeCSV, mediaTypeMultipart:
+		var (
+			err  error
+			cerr *format.CSVError
+		)
+		req, err = csvTreeReq(r, mt)
+		if errors.As(err, &cerr) {
+			s.sendCSVError(r, w, cerr)
+			return
+		} else if err != nil {
+			s.sendBodyError(r, w, err, err.Error())
+			return
+		}
+	default:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.sendBodyError(r, w, err, "invalid request body")
+			return
+		}
 	}
 
 	var leaves [][]byte
+	if req.EIP712 != nil {
+		if len(req.Leaves) > 0 {
+			s.sendJSONError(r, w, nil, http.StatusBadRequest, "provide either unhashedLeaves or eip712")
+			return
+		}
+		var err error
+		leaves, err = req.EIP712.leaves()
+		if err != nil {
+			s.sendFieldError(r, w, err, "eip712", "invalid eip712 message: "+err.Error())
+			return
+		}
+		req.Ltd = bytes32Ltd
+		req.Packed = true
+	}
 	for _, l := range req.Leaves {
 		// use the go-ethereum FromHex method because it is more
 		// lenient and will allow for odd-length hex strings (by padding them)
 		leaves = append(leaves, common.FromHex(l))
 	}
 
-	var (
-		tree   = merkle.New(leaves)
-		root   = tree.Root()
-		exists bool
-	)
-
-	const existsQ = `
-	select exists(
-		select 1 from trees where root = $1
-	)
-	`
-
-	err := s.db.QueryRow(ctx, existsQ, root).Scan(&exists)
-
-	if err != nil {
-		s.sendJSONError(r, w, err, http.StatusInternalServerError, "failed to check if tree already exists")
+	switch len(leaves) {
+	case 0:
+		s.sendJSONError(r, w, nil, http.StatusBadRequest, "No leaves provided")
+		return
+	case 1:
+		s.sendJSONError(r, w, nil, http.StatusBadRequest, "You must provide at least two values")
 		return
 	}
-
-	if exists {
-		s.sendJSON(r, w, createTreeResp{hexutil.Encode(root)})
+	if lerr := s.limits.checkTree(leaves, req.Ltd); lerr != nil {
+		s.sendCodedError(r, w, nil, lerr.code, CodeLimitExceeded, lerr.msg)
 		return
 	}
+	if req.TTL < 0 {
+		s.sendFieldError(r, w, nil, "ttl", "ttl must not be negative")
+		return
+	}
+	if req.Metadata != nil {
+		if err := req.Metadata.validate(); err != nil {
+			s.sendValidationError(r, w, err, "metadata.")
+			return
+		}
+	}
+
+	// analyze before salting since the salt
+	// makes every leaf unique and the same length
+	warnings := merkle.Analyze(leaves, req.Ltd, req.Packed)
+
+	if req.Salted {
+		if req.EIP712 != nil {
+			s.sendJSONError(r, w, nil, http.StatusBadRequest, "eip712 trees cannot be salted")
+			return
+		}
+		var err error
+		leaves, err = saltLeaves(leaves)
+		if err != nil {
+			s.sendJSONError(r, w, err, http.StatusInternalServerError, "generating salts")
+			return
+		}
+	}
 
 	var (
-		proofHashes = make([][]any, 0, len(leaves))
-		allProofs   = tree.LeafProofs()
+		tree = s.buildTree(ctx, leaves)
+		root = tree.Root()
 	)
 
-	for _, p := range allProofs {
-		proofHash := hashProof(p)
-		proofHashes = append(proofHashes, []any{root, proofHash})
+	var owner common.Address
+	if len(req.Signature) > 0 {
+		if req.Salted {
+			// the root is random so it can't be signed in advance
+			s.sendJSONError(r, w, nil, http.StatusBadRequest, "salted trees cannot be signed")
+			return
+		}
+		var err error
+		owner, err = recoverSigner(root, req.Signature)
+		if err != nil {
+			s.sendJSONError(r, w, nil, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 
-	if err != nil {
-		s.sendJSONError(r, w, err, http.StatusBadRequest, "generating proofs for tree")
-		return
+	var expiresAt time.Time
+	if req.TTL > 0 {
+		expiresAt = time.Now().Add(time.Duration(req.TTL) * time.Second)
 	}
-
-	const q = `
-		INSERT INTO trees(
-			root,
-			unhashed_leaves,
-			ltd,
-			packed
-		) VALUES ($1, $2, $3, $4)
-		ON CONFLICT (root)
-		DO NOTHING
-	`
-
-	tx, err := s.db.Begin(ctx)
+	inserted, err := s.insertTree(ctx, tree, TreeRecord{
+		Leaves:    leaves,
+		Ltd:       req.Ltd,
+		Packed:    req.Packed,
+		Salted:    req.Salted,
+		TypedData: req.EIP712,
+		ExpiresAt: expiresAt,
+	})
 	if err != nil {
-		s.sendJSONError(r, w, err, http.StatusInternalServerError, "creating transaction")
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "inserting tree")
 		return
 	}
-	defer tx.Rollback(ctx)
+	if !inserted {
+		// the tree must last as long as any of its creators asked
+		if err := s.store.ExtendExpiry(ctx, root, expiresAt); err != nil {
+			s.sendJSONError(r, w, err, http.StatusInternalServerError, "extending expiry")
+			return
+		}
+	}
 
-	_, err = tx.Exec(ctx, q,
-		tree.Root(),
-		leaves,
-		req.Ltd,
-		req.Packed,
-	)
-	if err != nil {
-		s.sendJSONError(r, w, err, http.StatusInternalServerError, "inserting tree")
-		return
+	resp := createTreeResp{MerkleRoot: hexutil.Encode(root), Warnings: warnings}
+	if len(req.Signature) > 0 {
+		// only the request that created the tree can
+		// claim it, otherwise anyone could claim trees
+		// that were created without a signature
+		ok := false
+		if inserted {
+			ok, err = s.store.InsertOwner(ctx, root, owner)
+			if err != nil {
+				s.sendJSONError(r, w, err, http.StatusInternalServerError, "inserting owner")
+				return
+			}
+		}
+		if !ok {
+			own, err := s.store.GetOwner(ctx, root)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting owner")
+				return
+			}
+			if err != nil || own.Owner != owner {
+				s.sendCodedError(r, w, nil, http.StatusConflict, CodeOwnerConflict, "tree already exists with a different owner")
+				return
+			}
+		}
+		resp.Owner = &owner
+	}
+	// metadata can only be attached by the request that
+	// created the tree or by its owner, otherwise anyone
+	// could name a tree by posting its leaves again
+	if req.Metadata != nil && (inserted || len(req.Signature) > 0) {
+		token, hash, err := newEditToken()
+		if err != nil {
+			s.sendJSONError(r, w, err, http.StatusInternalServerError, "generating edit token")
+			return
+		}
+		// metadata is only stored by the first
+		// request to provide it for a root
+		ok, err := s.store.InsertMetadata(ctx, root, MetadataRecord{
+			Metadata:      *req.Metadata,
+			EditTokenHash: hash,
+		})
+		if err != nil {
+			s.sendJSONError(r, w, err, http.StatusInternalServerError, "inserting metadata")
+			return
+		}
+		if ok {
+			resp.EditToken = token
+		}
 	}
 
-	_, err = tx.CopyFrom(ctx, pgx.Identifier{"proofs_hashes"},
-		[]string{"root", "hash"},
-		pgx.CopyFromRows(proofHashes),
-	)
+	s.sendJSON(r, w, resp)
+}
+
+// Stores the tree along with the hashes of its proofs.
+// Nothing is written if a tree with the same root already exists.
+// Reports whether the tree was inserted.
+func (s *Server) insertTree(ctx context.Context, tree merkle.Tree, rec TreeRecord) (bool, error) {
+	rec.Root = tree.Root()
 
+	exists, err := s.store.TreeExists(ctx, rec.Root)
 	if err != nil {
-		s.sendJSONError(r, w, err, http.StatusInternalServerError, "inserting proof hashes")
-		return
+		return false, fmt.Errorf("checking if tree exists: %w", err)
+	}
+	if exists {
+		return false, nil
 	}
 
-	err = tx.Commit(ctx)
-	if err != nil {
-		s.sendJSONError(r, w, err, http.StatusInternalServerError, "committing transaction")
-		return
+	var (
+		allProofs   = tree.LeafProofs()
+		proofHashes = make([][]byte, 0, len(allProofs))
+	)
+	for _, p := range allProofs {
+		proofHashes = append(proofHashes, hashProof(p))
 	}
 
-	s.sendJSON(r, w, createTreeResp{hexutil.Encode(root)})
+	return true, s.store.InsertTree(ctx, rec, proofHashes)
 }
 
 type getTreeResp struct {
@@ -213,40 +465,97 @@ type getTreeResp struct {
 	LeafCount      int             `json:"leafCount"`
 	Ltd            []string        `json:"leafTypeDescriptor"`
 	Packed         bool            `json:"packedEncoding"`
+	TypedData      *TypedData      `json:"eip712,omitempty"`
+	Metadata       *Metadata       `json:"metadata,omitempty"`
+	Owner          *common.Address `json:"owner,omitempty"`
+	ExpiresAt      *time.Time      `json:"expiresAt,omitempty"`
+
+	// Salted trees only publish the hashes of their leaves
+	Salted     bool            `json:"salted,omitempty"`
+	LeafHashes []hexutil.Bytes `json:"leafHashes,omitempty"`
+
+	// Set when a page of leaves was requested
+	// and there are more leaves after the page
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
-func getTree(ctx context.Context, db *pgxpool.Pool, root []byte) (getTreeResp, error) {
-	const q = `
-		SELECT unhashed_leaves, ltd, packed
-		FROM trees
-		WHERE root = $1
-	`
-	tr := getTreeResp{}
-	err := db.QueryRow(ctx, q, root).Scan(
-		&tr.UnhashedLeaves,
-		&tr.Ltd,
-		&tr.Packed,
-	)
+func getTree(ctx context.Context, st Store, root []byte) (getTreeResp, error) {
+	rec, err := st.GetTree(ctx, root)
 	if err != nil {
-		return tr, err
+		return getTreeResp{}, err
+	}
+	tr := getTreeResp{
+		UnhashedLeaves: make([]hexutil.Bytes, 0, len(rec.Leaves)),
+		Ltd:            rec.Ltd,
+		Packed:         rec.Packed,
+		TypedData:      rec.TypedData,
+		Salted:         rec.Salted,
+		ExpiresAt:      nullTime(rec.ExpiresAt),
+	}
+	for _, l := range rec.Leaves {
+		tr.UnhashedLeaves = append(tr.UnhashedLeaves, l)
 	}
 	return tr, nil
 }
 
+const (
+	defaultLeafLimit = 1000
+	maxLeafLimit     = 10000
+)
+
+// Parses the limit and cursor query params. paged is
+// false when neither is set and every leaf is returned.
+// The cursor is the index of the first leaf in the page
+// but clients should treat it as opaque.
+func leafPage(q url.Values) (offset, limit int, paged bool, err error) {
+	var (
+		ls = q.Get("limit")
+		cs = q.Get("cursor")
+	)
+	if ls == "" && cs == "" {
+		return 0, 0, false, nil
+	}
+	limit = defaultLeafLimit
+	if ls != "" {
+		limit, err = strconv.Atoi(ls)
+		if err != nil || limit < 1 || limit > maxLeafLimit {
+			return 0, 0, false, &FieldError{"limit", fmt.Sprintf("limit must be between 1 and %d", maxLeafLimit)}
+		}
+	}
+	if cs != "" {
+		offset, err = strconv.Atoi(cs)
+		if err != nil || offset < 0 {
+			return 0, 0, false, &FieldError{"cursor", "invalid cursor"}
+		}
+	}
+	return offset, limit, true, nil
+}
+
 func (s *Server) GetTree(w http.ResponseWriter, r *http.Request) {
 	var (
 		ctx  = r.Context()
 		root = r.URL.Query().Get("root")
 	)
 	if root == "" {
-		s.sendJSONError(r, w, nil, http.StatusBadRequest, "missing root")
+		s.sendFieldError(r, w, nil, "root", "missing root")
+		return
+	}
+	offset, limit, paged, err := leafPage(r.URL.Query())
+	if err != nil {
+		s.sendValidationError(r, w, err, "")
 		return
 	}
 
-	tr, err := getTree(ctx, s.db, common.FromHex(root))
+	var tr getTreeResp
+	if paged {
+		tr, err = getLeaves(ctx, s.store, common.FromHex(root), offset, limit)
+	} else {
+		tr, err = getTree(ctx, s.store, common.FromHex(root))
+		tr.LeafCount = len(tr.UnhashedLeaves)
+	}
 
-	if errors.Is(err, pgx.ErrNoRows) {
-		s.sendJSONError(r, w, nil, http.StatusNotFound, "tree not found for root")
+	if errors.Is(err, ErrNotFound) {
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeTreeNotFound, "tree not found for root")
 		w.Header().Set("Cache-Control", "public, max-age=60")
 		return
 	} else if err != nil {
@@ -254,8 +563,65 @@ func (s *Server) GetTree(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tr.LeafCount = len(tr.UnhashedLeaves)
+	if tr.Salted {
+		for _, l := range tr.UnhashedLeaves {
+			tr.LeafHashes = append(tr.LeafHashes, crypto.Keccak256(l))
+		}
+		tr.UnhashedLeaves = []hexutil.Bytes{}
+	}
 
-	w.Header().Set("Cache-Control", "public, max-age=86400")
+	md, err := s.store.GetMetadata(ctx, common.FromHex(root))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting metadata")
+		return
+	} else if err == nil {
+		tr.Metadata = &md.Metadata
+	}
+	own, err := s.store.GetOwner(ctx, common.FromHex(root))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		s.sendJSONError(r, w, err, http.StatusInternalServerError, "selecting owner")
+		return
+	} else if err == nil && own.Hidden {
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		s.sendCodedError(r, w, nil, http.StatusNotFound, CodeTreeNotFound, "tree not found for root")
+		return
+	} else if err == nil {
+		tr.Owner = &own.Owner
+	}
+
+	if !s.tlru.Contains(common.HexToHash(root)) {
+		s.touchTree(ctx, common.FromHex(root))
+	}
+
+	// metadata and owners can be edited and trees can
+	// expire so they're cached for less time than the
+	// immutable leaves
+	if tr.Metadata != nil || tr.Owner != nil || tr.ExpiresAt != nil {
+		w.Header().Set("Cache-Control", "public, max-age=60")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+	}
 	s.sendJSON(r, w, tr)
 }
+
+func getLeaves(ctx context.Context, st Store, root []byte, offset, limit int) (getTreeResp, error) {
+	rec, n, err := st.GetLeaves(ctx, root, offset, limit)
+	if err != nil {
+		return getTreeResp{}, err
+	}
+	tr := getTreeResp{
+		UnhashedLeaves: make([]hexutil.Bytes, 0, len(rec.Leaves)),
+		LeafCount:      n,
+		Ltd:            rec.Ltd,
+		Packed:         rec.Packed,
+		Salted:         rec.Salted,
+		ExpiresAt:      nullTime(rec.ExpiresAt),
+	}
+	for _, l := range rec.Leaves {
+		tr.UnhashedLeaves = append(tr.UnhashedLeaves, l)
+	}
+	if next := offset + len(rec.Leaves); next < n {
+		tr.NextCursor = strconv.Itoa(next)
+	}
+	return tr, nil
+}
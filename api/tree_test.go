@@ -2,9 +2,12 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
+	"net/url"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
 func TestAddrUnpacked(t *testing.T) {
@@ -124,3 +127,129 @@ func TestLeaf2Addr(t *testing.T) {
 	}
 
 }
+
+func TestTypedDataAddr(t *testing.T) {
+	td := &TypedData{
+		Types: apitypes.Types{
+			"Mint": {
+				{Name: "quantity", Type: "uint256"},
+				{Name: "minter", Type: "address"},
+			},
+		},
+		PrimaryType: "Mint",
+		Messages: []apitypes.TypedDataMessage{
+			{"quantity": "1", "minter": "0x0000000000000000000000000000000000000001"},
+			{"quantity": "1", "minter": 1},
+		},
+	}
+
+	cases := []struct {
+		i    int
+		want []byte
+	}{
+		{0, common.FromHex("0x0000000000000000000000000000000000000001")},
+		{1, []byte{}},
+	}
+
+	for _, c := range cases {
+		addr := td.addr(c.i)
+		if !bytes.Equal(addr, c.want) {
+			t.Errorf("expected: %v got: %v", c.want, addr)
+		}
+	}
+}
+
+func TestTypedDataNumbers(t *testing.T) {
+	const body = `{
+		"domain": {"name": "Mint", "chainId": "1"},
+		"types": {"Mint": [{"name": "quantity", "type": "uint256"}]},
+		"primaryType": "Mint",
+		"messages": [{"quantity": 115792089237316195423570985008687907853269984665640564039457584007913129639935}]
+	}`
+	var td TypedData
+	if err := json.Unmarshal([]byte(body), &td); err != nil {
+		t.Fatal(err)
+	}
+	const want = "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+	if got := td.Messages[0]["quantity"]; got != want {
+		t.Errorf("expected: %s got: %v", want, got)
+	}
+	leaves, err := td.leaves()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same messages in another domain are other leaves
+	td.Domain.Name = "Other"
+	other, err := td.leaves()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(leaves[0], other[0]) {
+		t.Error("expected the domain to change the leaf")
+	}
+
+	td.Domain = apitypes.TypedDataDomain{}
+	if _, err := td.leaves(); err == nil {
+		t.Error("expected error for missing domain")
+	}
+}
+
+func TestSaltLeaves(t *testing.T) {
+	leaves := [][]byte{
+		common.FromHex("0x0000000000000000000000000000000000000001"),
+		common.FromHex("0x0000000000000000000000000000000000000001"),
+	}
+	salted, err := saltLeaves(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(salted[0], salted[1]) {
+		t.Error("expected salts to differ")
+	}
+	for i, l := range salted {
+		salt, payload := splitSalt(l, true)
+		if len(salt) != saltLen {
+			t.Errorf("expected %d byte salt got: %d", saltLen, len(salt))
+		}
+		if !bytes.Equal(payload, leaves[i]) {
+			t.Errorf("expected: %x got: %x", leaves[i], payload)
+		}
+	}
+
+	salt, payload := splitSalt(leaves[0], false)
+	if salt != nil || !bytes.Equal(payload, leaves[0]) {
+		t.Error("expected unsalted leaf to be returned as is")
+	}
+}
+
+func TestLeafPage(t *testing.T) {
+	cases := []struct {
+		query         string
+		offset, limit int
+		paged, err    bool
+	}{
+		{"", 0, 0, false, false},
+		{"limit=10", 0, 10, true, false},
+		{"cursor=20", 20, defaultLeafLimit, true, false},
+		{"limit=5&cursor=5", 5, 5, true, false},
+		{"limit=0", 0, 0, false, true},
+		{"limit=10001", 0, 0, false, true},
+		{"cursor=-1", 0, 0, false, true},
+		{"cursor=abc", 0, 0, false, true},
+	}
+	for _, c := range cases {
+		q, err := url.ParseQuery(c.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		offset, limit, paged, err := leafPage(q)
+		if (err != nil) != c.err {
+			t.Errorf("%q: expected error: %t got: %v", c.query, c.err, err)
+			continue
+		}
+		if offset != c.offset || limit != c.limit || paged != c.paged {
+			t.Errorf("%q: expected: %d %d %t got: %d %d %t", c.query, c.offset, c.limit, c.paged, offset, limit, paged)
+		}
+	}
+}
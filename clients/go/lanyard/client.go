@@ -8,21 +8,31 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/contextwtf/lanyard/merkle/format"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"golang.org/x/xerrors"
 )
 
+// Matches every 404 [Error] so that callers can use
+// errors.Is(err, ErrNotFound) whatever the resource.
+// 404s are returned as an *Error whose code tells which
+// resource was missing, so err == ErrNotFound no longer
+// matches and must be replaced with errors.Is.
 var ErrNotFound error = xerrors.New("resource not found")
 
 type Client struct {
 	httpClient *http.Client
 	url        string
+	apiKey     string
 }
 
 type ClientOpt func(*Client)
@@ -39,6 +49,15 @@ func WithClient(hc *http.Client) ClientOpt {
 	}
 }
 
+// WithAPIKey sends key with every request so that
+// the server applies the rate limits for the key
+// rather than those for the client's IP address.
+func WithAPIKey(key string) ClientOpt {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
 // Uses https://lanyard.org/api/v1 for a default url
 // and http.Client with a 30s timeout unless specified
 // using [WithURL] or [WithClient]
@@ -61,39 +80,32 @@ func (c *Client) sendRequest(
 	method, path string,
 	body, destination any,
 ) error {
-	var (
-		url   string = c.url + path
-		jsonb []byte
-		err   error
-	)
+	return c.sendRequestWithHeader(ctx, method, path, nil, body, destination)
+}
 
+func (c *Client) sendRequestWithHeader(
+	ctx context.Context,
+	method, path string,
+	header http.Header,
+	body, destination any,
+) error {
+	var jsonb []byte
 	if body != nil {
+		var err error
 		jsonb, err = json.Marshal(body)
 		if err != nil {
 			return err
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(jsonb))
-	if err != nil {
-		return xerrors.Errorf("error creating request: %w", err)
+	if header == nil {
+		header = http.Header{}
 	}
+	header.Set("Content-Type", "application/json")
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "lanyard-go+v1.0.3")
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(ctx, method, path, header, bytes.NewReader(jsonb))
 	if err != nil {
-		return xerrors.Errorf("failed to send request: %w", err)
-	}
-
-	if resp.StatusCode >= 400 {
-		// special case 404s to make consuming client API easier
-		if resp.StatusCode == http.StatusNotFound {
-			return ErrNotFound
-		}
-
-		return xerrors.Errorf("error making http request: %s", resp.Status)
+		return err
 	}
 
 	defer resp.Body.Close()
@@ -105,15 +117,103 @@ func (c *Client) sendRequest(
 	return nil
 }
 
+// Sends the request and returns the response if the
+// status is successful. The caller must close the body.
+func (c *Client) do(
+	ctx context.Context,
+	method, path string,
+	header http.Header,
+	body io.Reader,
+) (*http.Response, error) {
+	url := c.url + path
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, xerrors.Errorf("error creating request: %w", err)
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("User-Agent", "lanyard-go+v1.0.3")
+	if c.apiKey != "" {
+		req.Header.Set("X-Api-Key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, newError(resp)
+	}
+
+	return resp, nil
+}
+
 type createTreeRequest struct {
-	UnhashedLeaves     []hexutil.Bytes `json:"unhashedLeaves"`
+	UnhashedLeaves     []hexutil.Bytes `json:"unhashedLeaves,omitempty"`
 	LeafTypeDescriptor []string        `json:"leafTypeDescriptor,omitempty"`
 	PackedEncoding     bool            `json:"packedEncoding"`
+	EIP712             *TypedData      `json:"eip712,omitempty"`
+	Salted             bool            `json:"salted,omitempty"`
+	Metadata           *Metadata       `json:"metadata,omitempty"`
+	Signature          hexutil.Bytes   `json:"signature,omitempty"`
+	TTL                int64           `json:"ttl,omitempty"`
+}
+
+// Metadata describes a tree so that it can be
+// recognised in explorers. Every field is optional.
+type Metadata struct {
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Creator     *common.Address `json:"creator,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	ChainID     uint64          `json:"chainId,omitempty"`
+	ExternalURL string          `json:"externalUrl,omitempty"`
+}
+
+// TypedData describes a tree built from EIP-712 messages.
+// Each leaf is the EIP-712 digest of a message.
+type TypedData struct {
+	// Domain is required. Integers in messages
+	// should be strings to keep their precision.
+	Domain      apitypes.TypedDataDomain    `json:"domain"`
+	Types       apitypes.Types              `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Messages    []apitypes.TypedDataMessage `json:"messages"`
+}
+
+// A Warning describes a risky property of the leaves
+// of a tree. Codes are listed in the merkle package.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	// Count is the number of leaves the warning applies to
+	Count int `json:"count,omitempty"`
+
+	// Indexes holds up to 10 example leaf indexes
+	Indexes []int `json:"indexes,omitempty"`
 }
 
 type CreateResponse struct {
 	// MerkleRoot is the root of the created merkle tree
 	MerkleRoot hexutil.Bytes `json:"merkleRoot"`
+
+	// Warnings lists risky properties of the leaves,
+	// such as duplicates or ambiguous encodings
+	Warnings []Warning `json:"warnings,omitempty"`
+
+	// EditToken is returned by CreateTreeWithMetadata
+	// if the metadata was stored. It is only returned
+	// once and is required by UpdateMetadata
+	EditToken string `json:"editToken,omitempty"`
+
+	// Owner is set by CreateSignedTree
+	Owner *common.Address `json:"owner,omitempty"`
 }
 
 // If you have a list of addresses for an allowlist, you can
@@ -168,6 +268,273 @@ func (c *Client) CreateTypedTree(
 	return resp, nil
 }
 
+// CreateEIP712Tree creates a tree whose leaves are the
+// EIP-712 digest of each message, so the struct used
+// for signatures also defines the allowlist entries.
+// Proofs for the tree include the typed message.
+func (c *Client) CreateEIP712Tree(
+	ctx context.Context,
+	td *TypedData,
+) (*CreateResponse, error) {
+	req := &createTreeRequest{
+		EIP712:         td,
+		PackedEncoding: true,
+	}
+
+	resp := &CreateResponse{}
+
+	err := c.sendRequest(ctx, http.MethodPost, "/tree", req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// CreateSaltedTree creates a tree where the server prepends
+// a random 32 byte salt to each leaf so that the published
+// leaf hashes cannot be brute-forced back to addresses.
+// Proofs include the salt and contracts must verify
+// keccak256(abi.encodePacked(salt, unhashedLeaf)).
+func (c *Client) CreateSaltedTree(
+	ctx context.Context,
+	unhashedLeaves []hexutil.Bytes,
+	leafTypeDescriptor []string,
+	packedEncoding bool,
+) (*CreateResponse, error) {
+	req := &createTreeRequest{
+		UnhashedLeaves:     unhashedLeaves,
+		LeafTypeDescriptor: leafTypeDescriptor,
+		PackedEncoding:     packedEncoding,
+		Salted:             true,
+	}
+
+	resp := &CreateResponse{}
+
+	err := c.sendRequest(ctx, http.MethodPost, "/tree", req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// CreateExpiringTree is like CreateTypedTree but the
+// tree is deleted once ttl has passed, which is useful for
+// tests. If the tree already exists its expiry is extended
+// to the later of the two.
+func (c *Client) CreateExpiringTree(
+	ctx context.Context,
+	unhashedLeaves []hexutil.Bytes,
+	leafTypeDescriptor []string,
+	packedEncoding bool,
+	ttl time.Duration,
+) (*CreateResponse, error) {
+	req := &createTreeRequest{
+		UnhashedLeaves:     unhashedLeaves,
+		LeafTypeDescriptor: leafTypeDescriptor,
+		PackedEncoding:     packedEncoding,
+		TTL:                int64(ttl / time.Second),
+	}
+
+	resp := &CreateResponse{}
+
+	err := c.sendRequest(ctx, http.MethodPost, "/tree", req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// CreateTreeFromCSV creates a Merkle tree from a CSV
+// where the header row lists the type of each column, e.g.
+//
+//	address,uint256
+//	0x0000000000000000000000000000000000000001,100
+//
+// The server ABI encodes each row, using abi.encodePacked
+// if packed is true, and the header becomes the leaf type
+// descriptor. If any row is invalid no tree is created.
+func (c *Client) CreateTreeFromCSV(
+	ctx context.Context,
+	csv io.Reader,
+	packed bool,
+) (*CreateResponse, error) {
+	resp, err := c.do(
+		ctx, http.MethodPost,
+		fmt.Sprintf("/tree?packedEncoding=%t", packed),
+		http.Header{"Content-Type": {"text/csv"}}, csv,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	cr := &CreateResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(cr); err != nil {
+		return nil, xerrors.Errorf("failed to decode response: %w", err)
+	}
+
+	return cr, nil
+}
+
+// CreateTreeWithMetadata is like CreateTypedTree but also
+// stores metadata describing the tree. Metadata is only
+// stored by the first request to provide it for a root,
+// which receives an EditToken for use with UpdateMetadata.
+func (c *Client) CreateTreeWithMetadata(
+	ctx context.Context,
+	unhashedLeaves []hexutil.Bytes,
+	leafTypeDescriptor []string,
+	packedEncoding bool,
+	md *Metadata,
+) (*CreateResponse, error) {
+	req := &createTreeRequest{
+		UnhashedLeaves:     unhashedLeaves,
+		LeafTypeDescriptor: leafTypeDescriptor,
+		PackedEncoding:     packedEncoding,
+		Metadata:           md,
+	}
+
+	resp := &CreateResponse{}
+
+	err := c.sendRequest(ctx, http.MethodPost, "/tree", req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// UpdateMetadata replaces the metadata of a tree using the
+// EditToken returned by CreateTreeWithMetadata.
+// This endpoint will return ErrNotFound if the tree
+// has no metadata.
+func (c *Client) UpdateMetadata(
+	ctx context.Context,
+	root hexutil.Bytes,
+	editToken string,
+	md *Metadata,
+) (*Metadata, error) {
+	resp := &Metadata{}
+
+	err := c.sendRequestWithHeader(
+		ctx, http.MethodPut,
+		fmt.Sprintf("/tree/metadata?root=%s", root.String()),
+		http.Header{"Authorization": {"Bearer " + editToken}},
+		md, resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// CreateSignedTree is like CreateTypedTree but records the
+// signer of signature as the owner of the tree. signature is
+// a personal_sign (EIP-191) signature of the 32 byte root,
+// which can be computed with merkle.New before creating the
+// tree. Only the request that creates the tree can claim it.
+// See OwnerAction for what owners can do.
+func (c *Client) CreateSignedTree(
+	ctx context.Context,
+	unhashedLeaves []hexutil.Bytes,
+	leafTypeDescriptor []string,
+	packedEncoding bool,
+	signature hexutil.Bytes,
+) (*CreateResponse, error) {
+	req := &createTreeRequest{
+		UnhashedLeaves:     unhashedLeaves,
+		LeafTypeDescriptor: leafTypeDescriptor,
+		PackedEncoding:     packedEncoding,
+		Signature:          signature,
+	}
+
+	resp := &CreateResponse{}
+
+	err := c.sendRequest(ctx, http.MethodPost, "/tree", req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Actions that the owner of a tree can sign
+const (
+	OwnerUpdateMetadata = "updateMetadata"
+	OwnerHide           = "hide"
+	OwnerUnhide         = "unhide"
+	OwnerDelete         = "delete"
+)
+
+// OwnerMessage is the message signed by the owner
+// of a tree to perform an action on it.
+type OwnerMessage struct {
+	Action string        `json:"action"`
+	Root   hexutil.Bytes `json:"root"`
+
+	// Unix time that the message was signed. It must be within
+	// 10 minutes of the server's clock and after the timestamp
+	// of the owner's previous message.
+	Timestamp int64 `json:"timestamp"`
+
+	// Only used by OwnerUpdateMetadata
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+// NewOwnerMessage returns the JSON encoded message
+// to sign with personal_sign before calling OwnerAction.
+func NewOwnerMessage(action string, root hexutil.Bytes, md *Metadata) ([]byte, error) {
+	return json.Marshal(OwnerMessage{
+		Action:    action,
+		Root:      root,
+		Timestamp: time.Now().Unix(),
+		Metadata:  md,
+	})
+}
+
+type ownerActionRequest struct {
+	Message   string        `json:"message"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+type OwnerResponse struct {
+	Root    hexutil.Bytes  `json:"root"`
+	Owner   common.Address `json:"owner"`
+	Hidden  bool           `json:"hidden"`
+	Deleted bool           `json:"deleted,omitempty"`
+}
+
+// OwnerAction performs the action in msg, which must be
+// signed by the owner of the tree with personal_sign.
+// Owners can replace the metadata of a tree, hide it
+// from GetRootsFromProof, or delete it entirely.
+// This endpoint will return ErrNotFound if the tree
+// has no owner.
+func (c *Client) OwnerAction(
+	ctx context.Context,
+	msg []byte,
+	signature hexutil.Bytes,
+) (*OwnerResponse, error) {
+	req := &ownerActionRequest{
+		Message:   string(msg),
+		Signature: signature,
+	}
+
+	resp := &OwnerResponse{}
+
+	err := c.sendRequest(ctx, http.MethodPost, "/tree/owner", req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 type TreeResponse struct {
 	// UnhashedLeaves is a slice of addresses or ABI encoded types
 	UnhashedLeaves []hexutil.Bytes `json:"unhashedLeaves"`
@@ -180,6 +547,29 @@ type TreeResponse struct {
 	PackedEncoding bool `json:"packedEncoding"`
 
 	LeafCount int `json:"leafCount"`
+
+	// EIP712 is set for trees created with CreateEIP712Tree
+	EIP712 *TypedData `json:"eip712,omitempty"`
+
+	// Metadata is set for trees created with CreateTreeWithMetadata
+	Metadata *Metadata `json:"metadata,omitempty"`
+
+	// Owner is set for trees created with CreateSignedTree.
+	// Hidden trees are not returned by GetTree or
+	// GetRootsFromProof.
+	Owner *common.Address `json:"owner,omitempty"`
+
+	// ExpiresAt is set for trees created with CreateExpiringTree
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// Salted trees do not publish their unhashed leaves,
+	// only the hashes of the salted leaves
+	Salted     bool            `json:"salted,omitempty"`
+	LeafHashes []hexutil.Bytes `json:"leafHashes,omitempty"`
+
+	// NextCursor is set by GetTreePage when there
+	// are more leaves after the page
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // If a Merkle tree has been published to Lanyard, GetTreeFromRoot
@@ -205,9 +595,115 @@ func (c *Client) GetTreeFromRoot(
 	return resp, nil
 }
 
+// GetTreePage returns at most limit leaves of a tree
+// starting at cursor. Use an empty cursor for the first
+// page and the NextCursor of the response for the next.
+// LeafCount is the number of leaves in the whole tree.
+// EIP712 is not set on pages.
+// This endpoint will return ErrNotFound if the tree
+// associated with the root has not been published.
+func (c *Client) GetTreePage(
+	ctx context.Context,
+	root hexutil.Bytes,
+	limit int,
+	cursor string,
+) (*TreeResponse, error) {
+	resp := &TreeResponse{}
+
+	err := c.sendRequest(
+		ctx, http.MethodGet,
+		fmt.Sprintf("/tree?root=%s&limit=%d&cursor=%s", root.String(), limit, url.QueryEscape(cursor)),
+		nil, resp,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// TreePages iterates over the pages of a tree's leaves
+// so that large trees don't need to be loaded at once.
+//
+//	pages := c.TreePages(root, 1000)
+//	for pages.Next(ctx) {
+//		page := pages.Page()
+//		...
+//	}
+//	if err := pages.Err(); err != nil {
+//		...
+//	}
+type TreePages struct {
+	c      *Client
+	root   hexutil.Bytes
+	limit  int
+	cursor string
+	page   *TreeResponse
+	err    error
+	done   bool
+}
+
+// Returns an iterator over the leaves of the
+// tree with root in pages of at most limit leaves.
+func (c *Client) TreePages(root hexutil.Bytes, limit int) *TreePages {
+	return &TreePages{c: c, root: root, limit: limit}
+}
+
+// Fetches the next page. Returns false when there
+// are no more pages or an error has occurred.
+func (p *TreePages) Next(ctx context.Context) bool {
+	if p.done {
+		return false
+	}
+	p.page, p.err = p.c.GetTreePage(ctx, p.root, p.limit, p.cursor)
+	if p.err != nil {
+		p.done = true
+		return false
+	}
+	p.cursor = p.page.NextCursor
+	p.done = p.cursor == ""
+	return true
+}
+
+// Returns the page fetched by the last call to Next.
+func (p *TreePages) Page() *TreeResponse {
+	return p.page
+}
+
+// Returns the error, if any, that stopped the iteration.
+func (p *TreePages) Err() error {
+	return p.err
+}
+
 type ProofResponse struct {
 	UnhashedLeaf hexutil.Bytes   `json:"unhashedLeaf"`
 	Proof        []hexutil.Bytes `json:"proof"`
+
+	// TypedMessage is set for trees created with CreateEIP712Tree
+	TypedMessage apitypes.TypedDataMessage `json:"typedMessage,omitempty"`
+
+	// Salt is set for trees created with CreateSaltedTree.
+	// The leaf is keccak256(salt || unhashedLeaf)
+	Salt hexutil.Bytes `json:"salt,omitempty"`
+
+	// Matches is set for proofs requested by address and
+	// lists every leaf belonging to the address. The first
+	// match is the leaf and proof returned above
+	Matches []LeafProof `json:"matches,omitempty"`
+}
+
+type LeafProof struct {
+	ProofResponse
+
+	// Index is the position of the leaf in the tree
+	Index int `json:"index"`
+
+	// Fields is the leaf decoded using the leaf type
+	// descriptor. Addresses are checksummed, integers are
+	// base 10 and bytes are hex. Unset for EIP-712 trees
+	// and leaves that cannot be decoded
+	Fields []string `json:"fields,omitempty"`
 }
 
 // If the tree has been published to Lanyard,
@@ -262,6 +758,135 @@ func (c *Client) GetProofFromAddr(
 	return resp, nil
 }
 
+// GetProofFromAddrWhere is like GetProofFromAddr but
+// only returns leaves whose field equals value. For EIP-712
+// trees field is the name of a message field, otherwise it
+// is the position of the field in the leaf type descriptor,
+// e.g. "1" for the tier of an ["address","uint256"] leaf.
+// Returns ErrNotFound if no leaf matches.
+func (c *Client) GetProofFromAddrWhere(
+	ctx context.Context,
+	root, addr hexutil.Bytes,
+	field, value string,
+) (*ProofResponse, error) {
+	resp := &ProofResponse{}
+
+	err := c.sendRequest(
+		ctx, http.MethodGet,
+		fmt.Sprintf("/proof?root=%s&address=%s&field=%s&value=%s",
+			root.String(), addr.String(),
+			url.QueryEscape(field), url.QueryEscape(value),
+		),
+		nil, resp,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+type proofsRequest struct {
+	Root           hexutil.Bytes   `json:"root"`
+	UnhashedLeaves []hexutil.Bytes `json:"unhashedLeaves,omitempty"`
+	Addresses      []hexutil.Bytes `json:"addresses,omitempty"`
+}
+
+type BatchProof struct {
+	ProofResponse
+
+	// Address is set for proofs requested by address
+	Address hexutil.Bytes `json:"address,omitempty"`
+
+	// Found is false if the leaf or address is not in the tree
+	Found bool `json:"found"`
+}
+
+type ProofsResponse struct {
+	// Proofs in the same order as the requested leaves
+	UnhashedLeaves []BatchProof `json:"unhashedLeaves"`
+
+	// Proofs in the same order as the requested addresses.
+	// Matches lists every leaf belonging to the address.
+	Addresses []BatchProof `json:"addresses"`
+}
+
+// If the tree has been published to Lanyard, GetProofs
+// will return the proofs of many leaves and addresses
+// in one request. Leaves and addresses that are not in
+// the tree are returned with Found set to false.
+// At most 10,000 proofs can be requested at once.
+// This endpoint will return ErrNotFound if the tree
+// associated with the root has not been published.
+func (c *Client) GetProofs(
+	ctx context.Context,
+	root hexutil.Bytes,
+	unhashedLeaves, addrs []hexutil.Bytes,
+) (*ProofsResponse, error) {
+	resp := &ProofsResponse{}
+	req := &proofsRequest{
+		Root:           root,
+		UnhashedLeaves: unhashedLeaves,
+		Addresses:      addrs,
+	}
+
+	err := c.sendRequest(ctx, http.MethodPost, "/proofs", req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+type TreeProof struct {
+	// Index is the position of the leaf in the tree
+	Index        int             `json:"index"`
+	UnhashedLeaf hexutil.Bytes   `json:"unhashedLeaf"`
+	Proof        []hexutil.Bytes `json:"proof"`
+
+	// Address is set if the leaf contains an address
+	Address hexutil.Bytes `json:"address,omitempty"`
+}
+
+// If the tree has been published to Lanyard,
+// StreamTreeProofs calls fn with the proof of every
+// leaf in the tree in order. Proofs are decoded as they
+// are received so the tree is never held in memory.
+// If fn returns an error the stream is closed and the
+// error is returned. This endpoint will return ErrNotFound
+// if the tree associated with the root has not been published.
+// Trees created with CreateSaltedTree cannot be streamed.
+func (c *Client) StreamTreeProofs(
+	ctx context.Context,
+	root hexutil.Bytes,
+	fn func(*TreeProof) error,
+) error {
+	resp, err := c.do(
+		ctx, http.MethodGet,
+		fmt.Sprintf("/tree/proofs?root=%s", root.String()),
+		nil, nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for dec.More() {
+		p := &TreeProof{}
+		if err := dec.Decode(p); err != nil {
+			return xerrors.Errorf("failed to decode proof: %w", err)
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type RootsResponse struct {
 	Roots []hexutil.Bytes `json:"roots"`
 }
@@ -350,3 +975,241 @@ func addrPacked(leaf []byte, ltd []string) common.Address {
 	}
 	return common.Address{}
 }
+
+type AirdropBalance struct {
+	Address common.Address `json:"address"`
+	// Amount is a decimal or 0x prefixed hex string
+	Amount string `json:"amount"`
+}
+
+type createAirdropRequest struct {
+	Balances []AirdropBalance `json:"balances"`
+}
+
+type AirdropClaim struct {
+	// Account is only set by GetAirdropClaim
+	Account string          `json:"account,omitempty"`
+	Index   uint64          `json:"index"`
+	Amount  string          `json:"amount"`
+	Proof   []hexutil.Bytes `json:"proof"`
+}
+
+// AirdropResponse uses the claims format of
+// Uniswap's MerkleDistributor scripts.
+type AirdropResponse struct {
+	MerkleRoot hexutil.Bytes `json:"merkleRoot"`
+	TokenTotal string        `json:"tokenTotal"`
+	// Claims is keyed by checksummed address
+	Claims map[string]AirdropClaim `json:"claims"`
+}
+
+// CreateAirdrop creates a tree that can be used with
+// Uniswap's MerkleDistributor contract. Each leaf is
+// abi.encodePacked(uint256 index, address account, uint256 amount).
+func (c *Client) CreateAirdrop(
+	ctx context.Context,
+	balances []AirdropBalance,
+) (*AirdropResponse, error) {
+	req := &createAirdropRequest{Balances: balances}
+	resp := &AirdropResponse{}
+
+	err := c.sendRequest(ctx, http.MethodPost, "/airdrop", req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// If an airdrop has been published to Lanyard,
+// GetAirdropClaim will return the index, amount and proof
+// for an account. This endpoint will return ErrNotFound
+// if the airdrop or the account is not found.
+func (c *Client) GetAirdropClaim(
+	ctx context.Context,
+	root hexutil.Bytes,
+	addr common.Address,
+) (*AirdropClaim, error) {
+	resp := &AirdropClaim{}
+
+	err := c.sendRequest(
+		ctx, http.MethodGet,
+		fmt.Sprintf("/airdrop/claim?root=%s&address=%s",
+			root.String(), addr.Hex(),
+		),
+		nil, resp,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+type importTreeRequest struct {
+	Format             string               `json:"format"`
+	Layers             [][]string           `json:"layers,omitempty"`
+	UnhashedLeaves     []hexutil.Bytes      `json:"unhashedLeaves,omitempty"`
+	LeafTypeDescriptor []string             `json:"leafTypeDescriptor,omitempty"`
+	PackedEncoding     bool                 `json:"packedEncoding"`
+	Dump               *format.StandardDump `json:"dump,omitempty"`
+}
+
+// ImportHexLayers publishes a tree from a merkletreejs
+// getHexLayers dump. The dump only contains hashes so the
+// unhashed leaves must be provided in the same order.
+// The server checks that the root matches the dump.
+func (c *Client) ImportHexLayers(
+	ctx context.Context,
+	layers [][]string,
+	unhashedLeaves []hexutil.Bytes,
+	leafTypeDescriptor []string,
+	packedEncoding bool,
+) (*CreateResponse, error) {
+	req := &importTreeRequest{
+		Format:             "merkletreejs",
+		Layers:             layers,
+		UnhashedLeaves:     unhashedLeaves,
+		LeafTypeDescriptor: leafTypeDescriptor,
+		PackedEncoding:     packedEncoding,
+	}
+
+	resp := &CreateResponse{}
+
+	err := c.sendRequest(ctx, http.MethodPost, "/tree/import", req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ImportStandard publishes a tree from an OpenZeppelin
+// StandardMerkleTree dump. Only dumps whose tree shape
+// matches a Lanyard tree, such as those with a power of two
+// leaves, can be imported without changing the root.
+func (c *Client) ImportStandard(
+	ctx context.Context,
+	dump *format.StandardDump,
+) (*CreateResponse, error) {
+	req := &importTreeRequest{
+		Format: "openzeppelin",
+		Dump:   dump,
+	}
+
+	resp := &CreateResponse{}
+
+	err := c.sendRequest(ctx, http.MethodPost, "/tree/import", req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ExportHexLayers returns the tree in the format
+// of merkletreejs getHexLayers. This endpoint will return
+// ErrNotFound if the tree has not been published.
+func (c *Client) ExportHexLayers(
+	ctx context.Context,
+	root hexutil.Bytes,
+) ([][]string, error) {
+	var resp [][]string
+
+	err := c.sendRequest(
+		ctx, http.MethodGet,
+		fmt.Sprintf("/tree/export?root=%s&format=merkletreejs", root.String()),
+		nil, &resp,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ExportStandard returns the values of an unpacked typed tree
+// as an OpenZeppelin StandardMerkleTree dump. OpenZeppelin
+// hashes leaves twice so the dump has a different root.
+// This endpoint will return ErrNotFound if the tree
+// has not been published.
+func (c *Client) ExportStandard(
+	ctx context.Context,
+	root hexutil.Bytes,
+) (*format.StandardDump, error) {
+	resp := &format.StandardDump{}
+
+	err := c.sendRequest(
+		ctx, http.MethodGet,
+		fmt.Sprintf("/tree/export?root=%s&format=openzeppelin", root.String()),
+		nil, resp,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+type ConsistencyResponse struct {
+	FirstRoot  hexutil.Bytes   `json:"firstRoot"`
+	SecondRoot hexutil.Bytes   `json:"secondRoot"`
+	FirstSize  int             `json:"firstSize"`
+	SecondSize int             `json:"secondSize"`
+	Proof      []hexutil.Bytes `json:"proof"`
+}
+
+// If both trees have been published to Lanyard,
+// GetConsistencyProof returns a proof that the second tree
+// was created by appending leaves to the first tree.
+// The proof can be checked with merkle.ValidConsistency.
+// This endpoint will return ErrNotFound if either tree
+// has not been published.
+func (c *Client) GetConsistencyProof(
+	ctx context.Context,
+	first, second hexutil.Bytes,
+) (*ConsistencyResponse, error) {
+	resp := &ConsistencyResponse{}
+
+	err := c.sendRequest(
+		ctx, http.MethodGet,
+		fmt.Sprintf("/consistency?first=%s&second=%s",
+			first.String(), second.String(),
+		),
+		nil, resp,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Limits lists the largest requests and trees that the
+// server accepts. Zero means there is no limit.
+type Limits struct {
+	MaxBodyBytes int64 `json:"maxBodyBytes"`
+	MaxLeaves    int   `json:"maxLeaves"`
+	MaxLeafBytes int   `json:"maxLeafBytes"`
+
+	// MaxLeafTypeDescriptorLength is the maximum
+	// number of types in a leaf type descriptor
+	MaxLeafTypeDescriptorLength int `json:"maxLeafTypeDescriptorLength"`
+}
+
+// GetLimits returns the server's limits so that
+// large trees can be checked before they are sent.
+func (c *Client) GetLimits(ctx context.Context) (*Limits, error) {
+	resp := &Limits{}
+
+	err := c.sendRequest(ctx, http.MethodGet, "/limits", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
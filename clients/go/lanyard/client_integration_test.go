@@ -4,6 +4,7 @@ package lanyard
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 
@@ -74,7 +75,7 @@ func TestBasicMerkleProof(t *testing.T) {
 
 func TestBasicMerkleProof404(t *testing.T) {
 	_, err := client.GetProofFromLeaf(context.Background(), []byte{0x01}, hexutil.MustDecode("0x0000000000000000000000000000000000000001"))
-	if err != ErrNotFound {
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, ErrTreeNotFound) {
 		t.Fatal("expected custom 404 err type for invalid request, got %w", err)
 	}
 }
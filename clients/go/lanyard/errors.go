@@ -0,0 +1,116 @@
+package lanyard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/contextwtf/lanyard/merkle/format"
+)
+
+const (
+	ErrBadRequest       ErrorCode = "bad_request"
+	ErrForbidden        ErrorCode = "forbidden"
+	ErrConflict         ErrorCode = "conflict"
+	ErrTooLarge         ErrorCode = "too_large"
+	ErrRateLimited      ErrorCode = "rate_limited"
+	ErrInternal         ErrorCode = "internal"
+	ErrMethodNotAllowed ErrorCode = "method_not_allowed"
+	ErrInvalidField     ErrorCode = "invalid_field"
+	ErrInvalidCSV       ErrorCode = "invalid_csv"
+
+	// The request or tree exceeds one of the server's [Limits]
+	ErrLimitExceeded ErrorCode = "limit_exceeded"
+
+	// Sent with a 404, so errors with these codes
+	// also match [ErrNotFound]
+	ErrNotFoundCode     ErrorCode = "not_found"
+	ErrTreeNotFound     ErrorCode = "tree_not_found"
+	ErrLeafNotFound     ErrorCode = "leaf_not_found"
+	ErrProofNotFound    ErrorCode = "proof_not_found"
+	ErrMetadataNotFound ErrorCode = "metadata_not_found"
+	ErrOwnerNotFound    ErrorCode = "owner_not_found"
+	ErrAccountNotFound  ErrorCode = "account_not_found"
+	ErrNotAirdrop       ErrorCode = "not_airdrop"
+
+	ErrNotOwner         ErrorCode = "not_owner"
+	ErrInvalidEditToken ErrorCode = "invalid_edit_token"
+	ErrOwnerConflict    ErrorCode = "owner_conflict"
+	ErrSignatureExpired ErrorCode = "signature_expired"
+	ErrSignatureReplay  ErrorCode = "signature_replay"
+	ErrInconsistent     ErrorCode = "inconsistent_trees"
+)
+
+// An ErrorCode identifies the kind of an [Error]. Codes
+// are errors so that they can be matched with errors.Is:
+//
+//	errors.Is(err, lanyard.ErrLeafNotFound)
+type ErrorCode string
+
+func (c ErrorCode) Error() string {
+	return "lanyard: " + string(c)
+}
+
+// Describes an invalid field of a request. Fields are
+// named as they are in the request, such as balances[2].amount.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// An Error is returned for responses with an error status.
+// Every 404 matches [ErrNotFound] as well as its code.
+type Error struct {
+	StatusCode int          `json:"-"`
+	Code       ErrorCode    `json:"code"`
+	Message    string       `json:"message"`
+	RequestID  string       `json:"requestId"`
+	Details    []FieldError `json:"details"`
+
+	// The invalid cells of a CSV for [ErrInvalidCSV]
+	Rows []format.RowError `json:"rows"`
+}
+
+func (e *Error) Error() string {
+	s := fmt.Sprintf("lanyard: %d", e.StatusCode)
+	if e.Code != "" {
+		s += " " + string(e.Code)
+	}
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		s += " (request " + e.RequestID + ")"
+	}
+	return s
+}
+
+func (e *Error) Is(target error) bool {
+	if target == ErrNotFound {
+		return e.StatusCode == http.StatusNotFound
+	}
+	c, ok := target.(ErrorCode)
+	return ok && c == e.Code
+}
+
+// Reads the error from the body of resp. Servers
+// and proxies that don't send one are reported
+// using the status.
+func newError(resp *http.Response) *Error {
+	e := &Error{}
+	// the server sends small errors and anything
+	// else is unlikely to be an error from it
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil || json.Unmarshal(b, e) != nil {
+		e = &Error{}
+	}
+	e.StatusCode = resp.StatusCode
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	if e.RequestID == "" {
+		e.RequestID = resp.Header.Get("Request-Id")
+	}
+	return e
+}
@@ -1,19 +1,28 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime/debug"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/contextwtf/lanyard/api"
 	"github.com/contextwtf/lanyard/api/migrations"
 	"github.com/contextwtf/lanyard/api/tracing"
 	"github.com/contextwtf/migrate"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	_ "github.com/jackc/pgx/v4/stdlib"
@@ -21,8 +30,13 @@ import (
 	"github.com/pkg/profile"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/opentracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	_ "modernc.org/sqlite"
 )
 
 var GitSha string
@@ -49,16 +63,8 @@ func main() {
 		defer prof.Stop()
 	}
 
-	ddAgent := os.Getenv("DD_AGENT_HOST")
-	if ddAgent != "" {
-		t := opentracer.New(
-			tracer.WithEnv(os.Getenv("DD_ENV")),
-			tracer.WithService(os.Getenv("DD_SERVICE")),
-			tracer.WithServiceVersion(GitSha),
-			tracer.WithAgentAddr(net.JoinHostPort(ddAgent, "8126")),
-		)
-		opentracing.SetGlobalTracer(t)
-	}
+	trace, stopTracer := startTracer()
+	defer stopTracer()
 
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	if env == "dev" {
@@ -66,18 +72,122 @@ func main() {
 	}
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	var (
-		logger = log.Logger.With().Caller().Logger()
-		ctx    = logger.WithContext(context.Background())
+		logger      = log.Logger.With().Caller().Logger()
+		ctx, cancel = signal.NotifyContext(logger.WithContext(context.Background()), os.Interrupt, syscall.SIGTERM)
 	)
+	defer cancel()
 	const defaultPGURL = "postgres:///al"
 	dburl := os.Getenv("DATABASE_URL")
 	if dburl == "" {
 		dburl = defaultPGURL
 	}
+
+	var (
+		store api.Store
+		db    *pgxpool.Pool
+	)
+	if strings.HasPrefix(dburl, sqliteScheme) {
+		store = sqliteStore(ctx, strings.TrimPrefix(dburl, sqliteScheme))
+	} else {
+		db = pgPool(ctx, dburl, trace)
+		store = api.NewPGStore(db)
+	}
+	s := api.New(store)
+	if p, ok := purgeHook(); ok {
+		s.SetPurge(p)
+	}
+	go s.CollectGarbage(ctx, gcConfig())
+	if rl, ok := rateLimitConfig(ctx, db); ok {
+		s.SetRateLimits(rl)
+	}
+	s.SetLimits(limits())
+	serveMetrics(ctx, s)
+
+	const defaultListen = ":8080"
+	listen := os.Getenv("LISTEN")
+	if listen == "" {
+		listen = defaultListen
+	}
+	hs := &http.Server{
+		Addr:    listen,
+		Handler: s.Handler(env, GitSha),
+	}
+	// finish in-flight requests on SIGTERM so that
+	// deferred funcs, such as flushing spans, run
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := hs.Shutdown(sctx); err != nil {
+			log.Ctx(ctx).Err(err).Msg("shutting down http server")
+		}
+	}()
+	log.Ctx(ctx).Info().Str("listen", listen).Str("git-sha", GitSha).Msg("http server")
+	if err := hs.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		check(err)
+	}
+	<-done
+}
+
+// TRACER selects datadog or otel. Datadog is used if
+// DD_AGENT_HOST is set and otel sends spans to the OTLP
+// endpoint from OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT over HTTP.
+// Returns whether spans are being sent and a func
+// that flushes buffered spans, to be called on exit.
+func startTracer() (bool, func()) {
+	ddAgent := os.Getenv("DD_AGENT_HOST")
+	switch os.Getenv("TRACER") {
+	case "otel":
+		ctx := context.Background()
+		exp, err := otlptracehttp.New(ctx)
+		check(err)
+		res, err := resource.New(ctx,
+			resource.WithAttributes(
+				semconv.ServiceName("lanyard"),
+				semconv.ServiceVersion(GitSha),
+			),
+			// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+			resource.WithFromEnv(),
+		)
+		check(err)
+		tp := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exp),
+			sdktrace.WithResource(res),
+		)
+		tracing.UseOpenTelemetry(tp)
+		return true, func() {
+			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			if err := tp.Shutdown(ctx); err != nil {
+				log.Err(err).Msg("shutting down tracer")
+			}
+		}
+	case "", "datadog":
+		if ddAgent == "" {
+			return false, func() {}
+		}
+		t := opentracer.New(
+			tracer.WithEnv(os.Getenv("DD_ENV")),
+			tracer.WithService(os.Getenv("DD_SERVICE")),
+			tracer.WithServiceVersion(GitSha),
+			tracer.WithAgentAddr(net.JoinHostPort(ddAgent, "8126")),
+		)
+		opentracing.SetGlobalTracer(t)
+		return true, tracer.Stop
+	default:
+		check(fmt.Errorf("unknown TRACER %q", os.Getenv("TRACER")))
+		return false, func() {}
+	}
+}
+
+func pgPool(ctx context.Context, dburl string, trace bool) *pgxpool.Pool {
 	dbc, err := pgxpool.ParseConfig(dburl)
 	check(err)
 
-	if ddAgent != "" {
+	if trace {
 		// trace db queries if tracing is enabled
 		dbc.ConnConfig.Logger = tracing.NewDBTracer(
 			dbc.ConnConfig.Host,
@@ -98,17 +208,176 @@ func main() {
 	check(migrate.Run(ctx, mdb, migrations.Migrations))
 	check(mdb.Close())
 
-	s := api.New(db)
+	return db
+}
 
-	const defaultListen = ":8080"
-	listen := os.Getenv("LISTEN")
+// GC_INTERVAL sets how often expired trees are deleted,
+// or disables the collector if it is 0, and
+// PRUNE_UNREAD_DAYS enables deleting trees that have not
+// been read within that many days of being created.
+func gcConfig() api.GCConfig {
+	c := api.GCConfig{Interval: 10 * time.Minute}
+	if v := os.Getenv("GC_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		check(err)
+		c.Interval = d
+	}
+	if v := os.Getenv("PRUNE_UNREAD_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		check(err)
+		c.PruneUnread = time.Duration(n) * 24 * time.Hour
+	}
+	return c
+}
+
+// METRICS_LISTEN is the address, e.g. :9090, that serves
+// Prometheus metrics at /metrics. It should not be public.
+// Metrics are not served unless it is set.
+func serveMetrics(ctx context.Context, s *api.Server) {
+	listen := os.Getenv("METRICS_LISTEN")
 	if listen == "" {
-		listen = defaultListen
+		return
 	}
-	hs := &http.Server{
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.MetricsHandler())
+	ms := &http.Server{
 		Addr:    listen,
-		Handler: s.Handler(env, GitSha),
+		Handler: mux,
 	}
-	log.Ctx(ctx).Info().Str("listen", listen).Str("git-sha", GitSha).Msg("http server")
-	check(hs.ListenAndServe())
+	log.Ctx(ctx).Info().Str("listen", listen).Msg("metrics server")
+	go func() {
+		check(ms.ListenAndServe())
+	}()
+}
+
+// PURGE_URL is sent a POST of {"root": "0x..."} after a
+// tree is hidden or deleted so that its responses can be
+// purged from a CDN. Without it those trees are served from
+// caches until their max-age runs out.
+func purgeHook() (func(context.Context, []byte) error, bool) {
+	u := os.Getenv("PURGE_URL")
+	if u == "" {
+		return nil, false
+	}
+	c := &http.Client{Timeout: 10 * time.Second}
+	return func(ctx context.Context, root []byte) error {
+		b, err := json.Marshal(map[string]string{"root": hexutil.Encode(root)})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := c.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("purge: %s", resp.Status)
+		}
+		return nil
+	}, true
+}
+
+// MAX_BODY_BYTES, MAX_LEAVES, MAX_LEAF_BYTES and
+// MAX_LTD_LENGTH override api.DefaultLimits.
+// Setting one to 0 disables it.
+func limits() api.Limits {
+	l := api.DefaultLimits
+	if v := os.Getenv("MAX_BODY_BYTES"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		check(err)
+		l.MaxBodyBytes = n
+	}
+	for env, dst := range map[string]*int{
+		"MAX_LEAVES":     &l.MaxLeaves,
+		"MAX_LEAF_BYTES": &l.MaxLeafBytes,
+		"MAX_LTD_LENGTH": &l.MaxLtdLength,
+	} {
+		if v := os.Getenv(env); v != "" {
+			n, err := strconv.Atoi(v)
+			check(err)
+			*dst = n
+		}
+	}
+	return l
+}
+
+// RATE_LIMIT_CREATE and RATE_LIMIT_READ limit each IP
+// address, e.g. 60/m. Keys listed in RATE_LIMIT_KEYS get
+// RATE_LIMIT_KEY_CREATE and RATE_LIMIT_KEY_READ instead,
+// each of which defaults to the IP address limit.
+// RATE_LIMIT_SHARED=true keeps the buckets in Postgres
+// so that every server shares the limits.
+// RATE_LIMIT_TRUST_PROXY=true limits the IP address set by
+// a proxy in fastly-client-ip or x-forwarded-for instead
+// of the address of the connection.
+// Requests are not limited unless one of these is set.
+func rateLimitConfig(ctx context.Context, db *pgxpool.Pool) (api.RateLimitConfig, bool) {
+	var (
+		c  api.RateLimitConfig
+		kl api.RateLimits
+		ok bool
+	)
+	for env, dst := range map[string]*api.RateLimit{
+		"RATE_LIMIT_CREATE":     &c.Create,
+		"RATE_LIMIT_READ":       &c.Read,
+		"RATE_LIMIT_KEY_CREATE": &kl.Create,
+		"RATE_LIMIT_KEY_READ":   &kl.Read,
+	} {
+		if v := os.Getenv(env); v != "" {
+			l, err := api.ParseRateLimit(v)
+			check(err)
+			*dst = l
+			ok = true
+		}
+	}
+	if keys := os.Getenv("RATE_LIMIT_KEYS"); keys != "" {
+		// a zero limit would leave keyed clients unlimited
+		if kl.Create == (api.RateLimit{}) {
+			kl.Create = c.Create
+		}
+		if kl.Read == (api.RateLimit{}) {
+			kl.Read = c.Read
+		}
+		c.Keys = map[string]api.RateLimits{}
+		for _, k := range strings.Split(keys, ",") {
+			c.Keys[strings.TrimSpace(k)] = kl
+		}
+	}
+	c.TrustProxy = os.Getenv("RATE_LIMIT_TRUST_PROXY") == "true"
+	if os.Getenv("RATE_LIMIT_SHARED") == "true" {
+		if db == nil {
+			check(fmt.Errorf("RATE_LIMIT_SHARED requires a postgres DATABASE_URL"))
+		}
+		rs := api.NewPGRateStore(db)
+		go func() {
+			for range time.Tick(time.Hour) {
+				if err := rs.Prune(ctx); err != nil {
+					log.Ctx(ctx).Err(err).Msg("pruning rate limits")
+				}
+			}
+		}()
+		c.Store = rs
+	}
+	return c, ok
+}
+
+// DATABASE_URL=sqlite://lanyard.db or sqlite://:memory:
+// The remainder of the url is passed to the driver
+// so it may also be a file: URI.
+const sqliteScheme = "sqlite://"
+
+func sqliteStore(ctx context.Context, dsn string) api.Store {
+	db, err := sql.Open("sqlite", dsn)
+	check(err)
+	// SQLite allows a single writer and each connection
+	// to :memory: is a separate database so one
+	// connection is shared by every request
+	db.SetMaxOpenConns(1)
+	check(migrations.RunSQLite(ctx, db, migrations.SQLite))
+	return api.NewSQLiteStore(db)
 }
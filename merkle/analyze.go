@@ -0,0 +1,160 @@
+package merkle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// Warning codes reported by [Analyze].
+const (
+	// A leaf is 64 bytes long and therefore has the same
+	// length as the concatenation of two intermediary nodes.
+	// If a verifier accepts 64 byte leaves, an intermediary
+	// node pair can be presented as a leaf (second preimage).
+	WarnInternalNodeLength = "internal-node-length"
+
+	// The leaves do not all have the same length which
+	// usually means they were encoded inconsistently.
+	WarnMixedLeafLengths = "mixed-leaf-lengths"
+
+	// The same leaf appears more than once.
+	// Only the first occurrence can be found with [Tree.Index].
+	WarnDuplicateLeaves = "duplicate-leaves"
+
+	// The leaf type descriptor uses packed encoding with
+	// more than one dynamic type. Since packed encoding
+	// omits lengths, different values can produce the same bytes.
+	WarnAmbiguousPacked = "ambiguous-packed-encoding"
+)
+
+// Upper bound on the number of leaf indexes included in a [Warning].
+const maxWarningIndexes = 10
+
+// A Warning describes a risky property of a set of leaves.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	// Count is the number of leaves the warning applies to.
+	Count int `json:"count,omitempty"`
+
+	// Indexes holds up to 10 example leaf indexes.
+	Indexes []int `json:"indexes,omitempty"`
+}
+
+// Inspects the unhashed leaves of a tree and reports
+// properties that may make the tree unsafe to use on-chain.
+// ltd and packed describe how the leaves were encoded and
+// may be empty if the leaves are raw bytes.
+// See the package documentation for background.
+func Analyze(leaves [][]byte, ltd []string, packed bool) []Warning {
+	var (
+		warnings []Warning
+		long     []int
+		dups     []int
+		lengths  = map[int]int{}
+		seen     = make(map[string]struct{}, len(leaves))
+	)
+	for i, l := range leaves {
+		lengths[len(l)]++
+		if len(l) == 64 {
+			long = append(long, i)
+		}
+		if _, ok := seen[string(l)]; ok {
+			dups = append(dups, i)
+		}
+		seen[string(l)] = struct{}{}
+	}
+
+	if len(long) > 0 {
+		warnings = append(warnings, newWarning(
+			WarnInternalNodeLength,
+			"leaves are 64 bytes long and can be confused with intermediary nodes",
+			long,
+		))
+	}
+
+	if len(lengths) > 1 {
+		// leaves that differ from the most common
+		// length are likely the misencoded ones
+		var (
+			ls     []string
+			common int
+		)
+		for _, l := range sortedKeys(lengths) {
+			ls = append(ls, fmt.Sprintf("%d", l))
+			if lengths[l] > lengths[common] {
+				common = l
+			}
+		}
+		var odd []int
+		for i, l := range leaves {
+			if len(l) != common {
+				odd = append(odd, i)
+			}
+		}
+		warnings = append(warnings, newWarning(
+			WarnMixedLeafLengths,
+			fmt.Sprintf("leaves have different lengths (%s bytes)", strings.Join(ls, ", ")),
+			odd,
+		))
+	}
+
+	if len(dups) > 0 {
+		warnings = append(warnings, newWarning(
+			WarnDuplicateLeaves,
+			"leaves appear more than once in the tree",
+			dups,
+		))
+	}
+
+	if packed {
+		var dynamic []string
+		for _, desc := range ltd {
+			t, err := abi.NewType(desc, "", nil)
+			if err != nil {
+				continue
+			}
+			switch t.T {
+			case abi.StringTy, abi.BytesTy, abi.SliceTy:
+				dynamic = append(dynamic, desc)
+			}
+		}
+		if len(dynamic) > 1 {
+			warnings = append(warnings, Warning{
+				Code: WarnAmbiguousPacked,
+				Message: fmt.Sprintf(
+					"packed encoding of multiple dynamic types (%s) is ambiguous",
+					strings.Join(dynamic, ", "),
+				),
+			})
+		}
+	}
+
+	return warnings
+}
+
+func newWarning(code, msg string, indexes []int) Warning {
+	w := Warning{
+		Code:    code,
+		Message: msg,
+		Count:   len(indexes),
+		Indexes: indexes,
+	}
+	if len(w.Indexes) > maxWarningIndexes {
+		w.Indexes = w.Indexes[:maxWarningIndexes]
+	}
+	return w
+}
+
+func sortedKeys(m map[int]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,112 @@
+package merkle
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	cases := []struct {
+		desc   string
+		leaves [][]byte
+		ltd    []string
+		packed bool
+		want   []string
+	}{
+		{
+			desc: "addresses",
+			leaves: [][]byte{
+				bytes.Repeat([]byte{1}, 20),
+				bytes.Repeat([]byte{2}, 20),
+			},
+			ltd:    []string{"address"},
+			packed: true,
+		},
+		{
+			desc: "unpacked address, uint256",
+			leaves: [][]byte{
+				bytes.Repeat([]byte{1}, 64),
+				bytes.Repeat([]byte{2}, 64),
+			},
+			ltd:  []string{"address", "uint256"},
+			want: []string{WarnInternalNodeLength},
+		},
+		{
+			desc: "mixed and duplicate",
+			leaves: [][]byte{
+				[]byte("a"),
+				[]byte("bb"),
+				[]byte("a"),
+			},
+			want: []string{WarnMixedLeafLengths, WarnDuplicateLeaves},
+		},
+		{
+			desc: "packed dynamic types",
+			leaves: [][]byte{
+				[]byte("ab"),
+				[]byte("cd"),
+			},
+			ltd:    []string{"string", "string"},
+			packed: true,
+			want:   []string{WarnAmbiguousPacked},
+		},
+		{
+			desc: "unpacked dynamic types",
+			leaves: [][]byte{
+				[]byte("ab"),
+				[]byte("cd"),
+			},
+			ltd: []string{"string", "string"},
+		},
+	}
+
+	for _, tc := range cases {
+		ws := Analyze(tc.leaves, tc.ltd, tc.packed)
+		if len(ws) != len(tc.want) {
+			t.Errorf("%s: expected %d warnings got: %+v", tc.desc, len(tc.want), ws)
+			continue
+		}
+		for i := range ws {
+			if ws[i].Code != tc.want[i] {
+				t.Errorf("%s: expected: %s got: %s", tc.desc, tc.want[i], ws[i].Code)
+			}
+		}
+	}
+}
+
+func TestAnalyzeIndexes(t *testing.T) {
+	var leaves [][]byte
+	for i := 0; i < 20; i++ {
+		leaves = append(leaves, []byte("a"))
+	}
+	ws := Analyze(leaves, nil, false)
+	if len(ws) != 1 {
+		t.Fatalf("expected 1 warning got: %+v", ws)
+	}
+	if ws[0].Count != 19 {
+		t.Errorf("expected count 19 got: %d", ws[0].Count)
+	}
+	if len(ws[0].Indexes) != maxWarningIndexes || ws[0].Indexes[0] != 1 {
+		t.Errorf("unexpected indexes: %v", ws[0].Indexes)
+	}
+}
+
+func TestAnalyzeMixedLengths(t *testing.T) {
+	leaves := [][]byte{
+		[]byte("aa"),
+		[]byte("b"),
+		[]byte("cc"),
+		[]byte("dd"),
+		[]byte("eee"),
+	}
+	ws := Analyze(leaves, nil, false)
+	if len(ws) != 1 || ws[0].Code != WarnMixedLeafLengths {
+		t.Fatalf("expected %s got: %+v", WarnMixedLeafLengths, ws)
+	}
+	if ws[0].Count != 2 {
+		t.Errorf("expected count 2 got: %d", ws[0].Count)
+	}
+	if len(ws[0].Indexes) != 2 || ws[0].Indexes[0] != 1 || ws[0].Indexes[1] != 4 {
+		t.Errorf("expected indexes [1 4] got: %v", ws[0].Indexes)
+	}
+}
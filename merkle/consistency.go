@@ -0,0 +1,111 @@
+package merkle
+
+import (
+	"bytes"
+)
+
+// Trees built by [New] have the same shape as the trees in
+// Certificate Transparency (RFC 9162): the left subtree of
+// every node is complete and its size is the largest power
+// of two smaller than the number of leaves beneath the node.
+// Therefore appending leaves never changes earlier subtrees
+// and consistency between two versions of a tree can be
+// proven using the algorithm from RFC 9162 with sorted
+// pair hashing in place of ordered hashing.
+
+// Returns the largest power of two smaller than n.
+func splitPoint(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// Returns the root of a tree using hashes as its leaves.
+func subtreeRoot(hashes [][]byte) []byte {
+	level := hashes
+	for len(level) > 1 {
+		level = hashMerge(level)
+	}
+	return level[0]
+}
+
+// Returns a list of hashes proving that the tree built
+// from the first m leaves of t is a prefix of t. That is,
+// t was created by appending leaves to the smaller tree
+// without changing or removing any of its leaves.
+// The result of this func will be used in [ValidConsistency].
+// If m is not between 1 and the number of leaves, returns nil.
+func (t Tree) ConsistencyProof(m int) [][]byte {
+	if m < 1 || m > len(t[0]) {
+		return nil
+	}
+	return subproof(m, t[0], true)
+}
+
+func subproof(m int, hashes [][]byte, complete bool) [][]byte {
+	n := len(hashes)
+	if m == n {
+		if complete {
+			return nil
+		}
+		return [][]byte{subtreeRoot(hashes)}
+	}
+	k := splitPoint(n)
+	if m <= k {
+		return append(
+			subproof(m, hashes[:k], complete),
+			subtreeRoot(hashes[k:]),
+		)
+	}
+	return append(
+		subproof(m-k, hashes[k:], false),
+		subtreeRoot(hashes[:k]),
+	)
+}
+
+// Reports whether proof shows that the tree with secondRoot
+// and n leaves extends the tree with firstRoot and m leaves.
+// For details on how the proof is created, see [Tree.ConsistencyProof].
+func ValidConsistency(firstRoot, secondRoot []byte, m, n int, proof [][]byte) bool {
+	switch {
+	case m < 1 || m > n:
+		return false
+	case m == n:
+		return len(proof) == 0 && bytes.Equal(firstRoot, secondRoot)
+	}
+
+	if m&(m-1) == 0 { // m is a power of two
+		proof = append([][]byte{firstRoot}, proof...)
+	}
+	if len(proof) == 0 {
+		return false
+	}
+
+	fn, sn := m-1, n-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = HashPair(c, fr)
+			sr = HashPair(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = HashPair(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(fr, firstRoot) && bytes.Equal(sr, secondRoot)
+}
@@ -0,0 +1,52 @@
+package merkle
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestConsistencyProof(t *testing.T) {
+	var leaves [][]byte
+	for i := 0; i < 33; i++ {
+		leaves = append(leaves, []byte(fmt.Sprintf("leaf-%d", i)))
+	}
+
+	for n := 1; n <= len(leaves); n++ {
+		second := New(leaves[:n])
+		for m := 1; m <= n; m++ {
+			first := New(leaves[:m])
+			pf := second.ConsistencyProof(m)
+			if !ValidConsistency(first.Root(), second.Root(), m, n, pf) {
+				t.Fatalf("invalid proof for m=%d n=%d", m, n)
+			}
+			if m == n {
+				continue
+			}
+
+			// a tree with a different leaf is not consistent
+			changed := New(append([][]byte{[]byte("changed")}, leaves[1:m]...))
+			if ValidConsistency(changed.Root(), second.Root(), m, n, pf) {
+				t.Fatalf("expected invalid proof for changed leaf m=%d n=%d", m, n)
+			}
+			if len(pf) > 0 {
+				tampered := append([][]byte{[]byte("tampered")}, pf[1:]...)
+				if ValidConsistency(first.Root(), second.Root(), m, n, tampered) {
+					t.Fatalf("expected invalid proof for tampered hash m=%d n=%d", m, n)
+				}
+			}
+		}
+	}
+}
+
+func TestConsistencyProofBounds(t *testing.T) {
+	mt := New([][]byte{
+		[]byte("a"),
+		[]byte("b"),
+	})
+	if mt.ConsistencyProof(0) != nil || mt.ConsistencyProof(3) != nil {
+		t.Error("expected nil proof for out of range size")
+	}
+	if ValidConsistency(mt.Root(), mt.Root(), 3, 2, nil) {
+		t.Error("expected first size larger than second to be invalid")
+	}
+}
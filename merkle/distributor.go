@@ -0,0 +1,164 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Leaf type descriptor for leaves of a [Distributor].
+// The leaves are abi.encodePacked(index, account, amount)
+// as expected by Uniswap's MerkleDistributor contract.
+var DistributorLtd = []string{"uint256", "address", "uint256"}
+
+const distributorLeafLen = 32 + 20 + 32
+
+// An account and the amount of tokens it may claim.
+type Balance struct {
+	Account common.Address
+	Amount  *big.Int
+}
+
+// A single entry in a [Distributor].
+type DistributorClaim struct {
+	Index   uint64
+	Account common.Address
+	Amount  *big.Int
+}
+
+// Encodes a [DistributorClaim] as
+// abi.encodePacked(uint256 index, address account, uint256 amount).
+type DistributorEncoder struct{}
+
+func (DistributorEncoder) EncodeLeaf(c DistributorClaim) ([]byte, error) {
+	if c.Amount == nil || c.Amount.Sign() < 0 || c.Amount.BitLen() > 256 {
+		return nil, errors.New("merkle: amount out of uint256 range")
+	}
+	leaf := make([]byte, 0, distributorLeafLen)
+	leaf = append(leaf, math.U256Bytes(new(big.Int).SetUint64(c.Index))...)
+	leaf = append(leaf, c.Account.Bytes()...)
+	leaf = append(leaf, math.U256Bytes(new(big.Int).Set(c.Amount))...)
+	return leaf, nil
+}
+
+// Decodes a leaf created by [DistributorEncoder].
+func DecodeDistributorLeaf(leaf []byte) (DistributorClaim, error) {
+	if len(leaf) != distributorLeafLen {
+		return DistributorClaim{}, fmt.Errorf("merkle: distributor leaf must be %d bytes", distributorLeafLen)
+	}
+	index := new(big.Int).SetBytes(leaf[:32])
+	if !index.IsUint64() {
+		return DistributorClaim{}, errors.New("merkle: distributor index out of range")
+	}
+	return DistributorClaim{
+		Index:   index.Uint64(),
+		Account: common.BytesToAddress(leaf[32:52]),
+		Amount:  new(big.Int).SetBytes(leaf[52:]),
+	}, nil
+}
+
+// A Distributor is a [Tree] for token airdrops that is
+// compatible with Uniswap's MerkleDistributor.
+//
+// Like the reference implementation, accounts are indexed
+// in order of their checksummed hex strings and leaves are
+// ordered by their hash, so the same balances produce
+// the same root as the reference scripts.
+type Distributor struct {
+	Tree Tree
+
+	// Unhashed leaves in tree order
+	Leaves [][]byte
+
+	// Claims ordered by index
+	Claims []DistributorClaim
+
+	// Sum of all claim amounts
+	Total *big.Int
+
+	// position of each claim in Leaves
+	positions []int
+}
+
+// Returns a Distributor for the balances.
+// Accounts must be unique and amounts must be positive.
+func NewDistributor(balances []Balance) (*Distributor, error) {
+	sorted := make([]Balance, len(balances))
+	copy(sorted, balances)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Account.Hex() < sorted[j].Account.Hex()
+	})
+
+	var (
+		claims = make([]DistributorClaim, 0, len(sorted))
+		leaves = make([][]byte, 0, len(sorted))
+		total  = new(big.Int)
+	)
+	for i, b := range sorted {
+		if i > 0 && sorted[i-1].Account == b.Account {
+			return nil, fmt.Errorf("merkle: duplicate account %s", b.Account.Hex())
+		}
+		if b.Amount == nil || b.Amount.Sign() <= 0 {
+			return nil, fmt.Errorf("merkle: invalid amount for account %s", b.Account.Hex())
+		}
+		c := DistributorClaim{
+			Index:   uint64(i),
+			Account: b.Account,
+			Amount:  b.Amount,
+		}
+		l, err := DistributorEncoder{}.EncodeLeaf(c)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, c)
+		leaves = append(leaves, l)
+		total.Add(total, b.Amount)
+	}
+	if total.BitLen() > 256 {
+		return nil, errors.New("merkle: total amount out of uint256 range")
+	}
+
+	var (
+		hashes = make([][]byte, len(leaves))
+		order  = make([]int, len(leaves))
+	)
+	for i := range leaves {
+		hashes[i] = crypto.Keccak256(leaves[i])
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return bytes.Compare(hashes[order[i]], hashes[order[j]]) == -1
+	})
+
+	var (
+		ordered   = make([][]byte, len(leaves))
+		positions = make([]int, len(leaves))
+	)
+	for pos, i := range order {
+		ordered[pos] = leaves[i]
+		positions[i] = pos
+	}
+
+	return &Distributor{
+		Tree:      New(ordered),
+		Leaves:    ordered,
+		Claims:    claims,
+		Total:     total,
+		positions: positions,
+	}, nil
+}
+
+func (d *Distributor) Root() []byte {
+	return d.Tree.Root()
+}
+
+// Returns the claim at index along with its proof.
+func (d *Distributor) Proof(index int) (DistributorClaim, [][]byte) {
+	return d.Claims[index], d.Tree.Proof(d.positions[index])
+}
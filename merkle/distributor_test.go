@@ -0,0 +1,82 @@
+package merkle
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDistributor(t *testing.T) {
+	balances := []Balance{
+		{common.HexToAddress("0x00000000000000000000000000000000000000bb"), big.NewInt(300)},
+		{common.HexToAddress("0x00000000000000000000000000000000000000Aa"), big.NewInt(200)},
+		{common.HexToAddress("0x0000000000000000000000000000000000000001"), big.NewInt(250)},
+	}
+	d, err := NewDistributor(balances)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Total.Cmp(big.NewInt(750)) != 0 {
+		t.Errorf("expected total 750 got: %s", d.Total)
+	}
+
+	// checksummed "0x...01" < "0x...AA" < "0x...bb"
+	wantOrder := []common.Address{balances[2].Account, balances[1].Account, balances[0].Account}
+	for i, c := range d.Claims {
+		if c.Index != uint64(i) || c.Account != wantOrder[i] {
+			t.Errorf("unexpected claim at %d: %+v", i, c)
+		}
+		claim, pf := d.Proof(i)
+		leaf, err := DistributorEncoder{}.EncodeLeaf(claim)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Valid(d.Root(), pf, leaf) {
+			t.Errorf("invalid proof for index %d", i)
+		}
+		dec, err := DecodeDistributorLeaf(leaf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec.Index != claim.Index || dec.Account != claim.Account || dec.Amount.Cmp(claim.Amount) != 0 {
+			t.Errorf("expected: %+v got: %+v", claim, dec)
+		}
+	}
+
+	reversed := []Balance{balances[2], balances[1], balances[0]}
+	d2, err := NewDistributor(reversed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(d.Root(), d2.Root()) {
+		t.Error("expected root to be independent of input order")
+	}
+}
+
+func TestDistributorInvalid(t *testing.T) {
+	cases := []struct {
+		desc     string
+		balances []Balance
+	}{
+		{
+			"duplicate",
+			[]Balance{
+				{common.HexToAddress("0x01"), big.NewInt(1)},
+				{common.HexToAddress("0x01"), big.NewInt(2)},
+			},
+		},
+		{
+			"zero amount",
+			[]Balance{
+				{common.HexToAddress("0x01"), big.NewInt(0)},
+			},
+		},
+	}
+	for _, tc := range cases {
+		if _, err := NewDistributor(tc.balances); err == nil {
+			t.Errorf("%s: expected error", tc.desc)
+		}
+	}
+}
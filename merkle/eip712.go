@@ -0,0 +1,77 @@
+package merkle
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Encodes EIP-712 messages as their signing digest
+// so that the struct definition used for signatures
+// also defines the leaves of a tree.
+//
+// The resulting leaf is
+// keccak256("\x19\x01" || domainSeparator || hashStruct(message))
+// so trees with the same messages in different domains
+// have different roots. The leaf is 32 bytes and is hashed
+// again by [New] so a contract verifies a message by
+// computing keccak256(abi.encodePacked(_hashTypedDataV4(hashStruct))).
+type EIP712Encoder struct {
+	Domain      apitypes.TypedDataDomain
+	Types       apitypes.Types
+	PrimaryType string
+}
+
+func (e EIP712Encoder) EncodeLeaf(msg apitypes.TypedDataMessage) ([]byte, error) {
+	if _, ok := e.Types[e.PrimaryType]; !ok {
+		return nil, errors.New("merkle: primary type is not defined")
+	}
+	if e.Domain == (apitypes.TypedDataDomain{}) {
+		return nil, errors.New("merkle: domain is required")
+	}
+	td := apitypes.TypedData{
+		Types:       e.Types,
+		PrimaryType: e.PrimaryType,
+		Domain:      e.Domain,
+	}
+	if _, ok := td.Types["EIP712Domain"]; !ok {
+		td.Types = make(apitypes.Types, len(e.Types)+1)
+		for k, v := range e.Types {
+			td.Types[k] = v
+		}
+		td.Types["EIP712Domain"] = domainType(e.Domain)
+	}
+	ds, err := td.HashStruct("EIP712Domain", e.Domain.Map())
+	if err != nil {
+		return nil, err
+	}
+	hs, err := td.HashStruct(e.PrimaryType, msg)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256([]byte("\x19\x01"), ds, hs), nil
+}
+
+// Returns the EIP712Domain type for the fields that are set
+// in d, in the order defined by EIP-712, for types that
+// don't define it.
+func domainType(d apitypes.TypedDataDomain) []apitypes.Type {
+	var t []apitypes.Type
+	if d.Name != "" {
+		t = append(t, apitypes.Type{Name: "name", Type: "string"})
+	}
+	if d.Version != "" {
+		t = append(t, apitypes.Type{Name: "version", Type: "string"})
+	}
+	if d.ChainId != nil {
+		t = append(t, apitypes.Type{Name: "chainId", Type: "uint256"})
+	}
+	if d.VerifyingContract != "" {
+		t = append(t, apitypes.Type{Name: "verifyingContract", Type: "address"})
+	}
+	if d.Salt != "" {
+		t = append(t, apitypes.Type{Name: "salt", Type: "bytes32"})
+	}
+	return t
+}
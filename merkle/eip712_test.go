@@ -0,0 +1,69 @@
+package merkle
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Example from https://eips.ethereum.org/EIPS/eip-712
+func TestEIP712Encoder(t *testing.T) {
+	enc := EIP712Encoder{
+		Domain: apitypes.TypedDataDomain{
+			Name:              "Ether Mail",
+			Version:           "1",
+			ChainId:           math.NewHexOrDecimal256(1),
+			VerifyingContract: "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC",
+		},
+		Types: apitypes.Types{
+			"Person": {
+				{Name: "name", Type: "string"},
+				{Name: "wallet", Type: "address"},
+			},
+			"Mail": {
+				{Name: "from", Type: "Person"},
+				{Name: "to", Type: "Person"},
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Mail",
+	}
+	msg := apitypes.TypedDataMessage{
+		"from": map[string]any{
+			"name":   "Cow",
+			"wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826",
+		},
+		"to": map[string]any{
+			"name":   "Bob",
+			"wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB",
+		},
+		"contents": "Hello, Bob!",
+	}
+
+	got, err := enc.EncodeLeaf(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the digest a wallet signs for the example
+	want := "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"
+	if common.Bytes2Hex(got) != want {
+		t.Errorf("expected: %s got: %x", want, got)
+	}
+
+	other := enc
+	other.Domain.ChainId = math.NewHexOrDecimal256(5)
+	if l, err := other.EncodeLeaf(msg); err != nil || common.Bytes2Hex(l) == want {
+		t.Errorf("expected a different leaf for another domain got: %x %v", l, err)
+	}
+
+	_, err = EIP712Encoder{Domain: enc.Domain, Types: enc.Types, PrimaryType: "Missing"}.EncodeLeaf(msg)
+	if err == nil {
+		t.Error("expected error for undefined primary type")
+	}
+	_, err = EIP712Encoder{Types: enc.Types, PrimaryType: enc.PrimaryType}.EncodeLeaf(msg)
+	if err == nil {
+		t.Error("expected error for missing domain")
+	}
+}
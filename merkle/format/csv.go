@@ -0,0 +1,238 @@
+package format
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// At most this many invalid cells are reported by [ReadCSV]
+const maxRowErrors = 100
+
+// A RowError describes an invalid cell in a CSV.
+// Row and Column start at 1 and the header is row 1
+// so that they match the cells of a spreadsheet.
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// A CSVError lists the invalid cells of a CSV. If there
+// are more than 100, only the first 100 are listed and
+// Total is the number of invalid cells.
+type CSVError struct {
+	Rows  []RowError
+	Total int
+}
+
+func (e *CSVError) Error() string {
+	if len(e.Rows) == 0 {
+		return "format: invalid csv"
+	}
+	r := e.Rows[0]
+	return fmt.Sprintf("format: %d invalid cells, row %d column %d: %s", e.Total, r.Row, r.Column, r.Message)
+}
+
+// Reads a CSV of leaf values and ABI encodes each row.
+// The header row lists the type of each column, e.g.
+// address,uint256, which becomes the leaf type descriptor.
+// A header cell may include a name after the type, e.g.
+// "uint256 amount". uint and int are aliases
+// for uint256 and int256. When packed is true rows are encoded
+// with abi.encodePacked, otherwise with abi.encode.
+// Invalid cells are returned as a [*CSVError].
+func ReadCSV(r io.Reader, packed bool) ([][]byte, []string, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("format: empty csv")
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("format: reading header: %w", err)
+	}
+
+	var (
+		ltd  = make([]string, 0, len(header))
+		args abi.Arguments
+		cerr = &CSVError{}
+		fail = func(row, col int, msg string) {
+			cerr.Total++
+			if len(cerr.Rows) < maxRowErrors {
+				cerr.Rows = append(cerr.Rows, RowError{Row: row, Column: col, Message: msg})
+			}
+		}
+	)
+	for i, h := range header {
+		desc := strings.Fields(h)
+		if len(desc) == 0 {
+			fail(1, i+1, "missing type")
+			continue
+		}
+		switch desc[0] {
+		case "uint", "int":
+			desc[0] += "256"
+		}
+		t, err := abi.NewType(desc[0], "", nil)
+		if err != nil || ((t.T == abi.UintTy || t.T == abi.IntTy) && t.Size%8 != 0) {
+			fail(1, i+1, fmt.Sprintf("invalid type %q", desc[0]))
+			continue
+		}
+		ltd = append(ltd, desc[0])
+		args = append(args, abi.Argument{Type: t})
+	}
+	if cerr.Total > 0 {
+		return nil, nil, cerr
+	}
+
+	// the reader checks that every row has
+	// the same number of fields as the header
+	var leaves [][]byte
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			fail(pe.StartLine, pe.Column, pe.Err.Error())
+			if errors.Is(pe.Err, csv.ErrFieldCount) {
+				continue
+			}
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("format: reading csv: %w", err)
+		}
+		row, _ := cr.FieldPos(0)
+
+		var (
+			leaf   []byte
+			vals   = make([]any, 0, len(rec))
+			rowErr bool
+		)
+		for i, cell := range rec {
+			cell = strings.TrimSpace(cell)
+			if packed {
+				b, err := packCell(args[i].Type, cell)
+				if err != nil {
+					fail(row, i+1, err.Error())
+					rowErr = true
+					continue
+				}
+				leaf = append(leaf, b...)
+				continue
+			}
+			v, err := csvValue(args[i].Type, cell)
+			if err != nil {
+				fail(row, i+1, err.Error())
+				rowErr = true
+				continue
+			}
+			vals = append(vals, v)
+		}
+		if rowErr {
+			continue
+		}
+		if !packed {
+			leaf, err = args.Pack(vals...)
+			if err != nil {
+				fail(row, 1, err.Error())
+				continue
+			}
+		}
+		leaves = append(leaves, leaf)
+	}
+	if cerr.Total > 0 {
+		return nil, nil, cerr
+	}
+	return leaves, ltd, nil
+}
+
+// Parses an integer cell and checks that it fits in t.
+func parseInt(t abi.Type, cell string) (*big.Int, error) {
+	i, ok := math.ParseBig256(cell)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", cell)
+	}
+	var min, max *big.Int
+	if t.T == abi.UintTy {
+		min, max = big.NewInt(0), new(big.Int).Lsh(big.NewInt(1), uint(t.Size))
+	} else {
+		max = new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+		min = new(big.Int).Neg(max)
+	}
+	if i.Cmp(min) < 0 || i.Cmp(max) >= 0 {
+		return nil, fmt.Errorf("integer %s out of range for %s", cell, t)
+	}
+	return i, nil
+}
+
+// Converts a cell into the Go type that
+// go-ethereum expects when packing t.
+func csvValue(t abi.Type, cell string) (any, error) {
+	switch t.T {
+	case abi.BoolTy:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool %q", cell)
+		}
+		return b, nil
+	case abi.UintTy, abi.IntTy:
+		i, err := parseInt(t, cell)
+		if err != nil {
+			return nil, err
+		}
+		return toABI(t, i.String())
+	}
+	return toABI(t, cell)
+}
+
+// Encodes a cell the same way as abi.encodePacked.
+func packCell(t abi.Type, cell string) ([]byte, error) {
+	switch t.T {
+	case abi.AddressTy:
+		if !common.IsHexAddress(cell) {
+			return nil, fmt.Errorf("invalid address %q", cell)
+		}
+		return common.HexToAddress(cell).Bytes(), nil
+	case abi.BoolTy:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool %q", cell)
+		}
+		if b {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case abi.UintTy, abi.IntTy:
+		i, err := parseInt(t, cell)
+		if err != nil {
+			return nil, err
+		}
+		// two's complement for negative ints
+		b := math.U256Bytes(i)
+		return b[len(b)-t.Size/8:], nil
+	case abi.FixedBytesTy, abi.BytesTy:
+		b, err := hexutil.Decode(cell)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes %q", cell)
+		}
+		if t.T == abi.FixedBytesTy && len(b) != t.Size {
+			return nil, fmt.Errorf("expected %d bytes got %d", t.Size, len(b))
+		}
+		return b, nil
+	case abi.StringTy:
+		return []byte(cell), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
@@ -0,0 +1,108 @@
+package format
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestReadCSV(t *testing.T) {
+	cases := []struct {
+		csv    string
+		packed bool
+		ltd    []string
+		leaves []string
+	}{
+		{
+			"address account,uint256 amount\n" +
+				"0x0000000000000000000000000000000000000001, 2\n",
+			false,
+			[]string{"address", "uint256"},
+			[]string{"0x00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002"},
+		},
+		{
+			"address,uint8,int16,bool,bytes2\n" +
+				"0x0000000000000000000000000000000000000001,255,-2,true,0x0a0b\n" +
+				"0x0000000000000000000000000000000000000002,0x10,1,false,0x0c0d\n",
+			true,
+			[]string{"address", "uint8", "int16", "bool", "bytes2"},
+			[]string{
+				"0x0000000000000000000000000000000000000001" + "ff" + "fffe" + "01" + "0a0b",
+				"0x0000000000000000000000000000000000000002" + "10" + "0001" + "00" + "0c0d",
+			},
+		},
+	}
+	for _, c := range cases {
+		leaves, ltd, err := ReadCSV(strings.NewReader(c.csv), c.packed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ltd, c.ltd) {
+			t.Errorf("expected: %v got: %v", c.ltd, ltd)
+		}
+		if len(leaves) != len(c.leaves) {
+			t.Fatalf("expected: %d leaves got: %d", len(c.leaves), len(leaves))
+		}
+		for i := range leaves {
+			if got := hexutil.Encode(leaves[i]); got != c.leaves[i] {
+				t.Errorf("leaf %d: expected: %s got: %s", i, c.leaves[i], got)
+			}
+		}
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	cases := []struct {
+		csv    string
+		packed bool
+		rows   []RowError
+	}{
+		{
+			"address,uint8\n" +
+				"0x0000000000000000000000000000000000000001,1\n" +
+				"0x01,256\n",
+			false,
+			[]RowError{
+				{Row: 3, Column: 1, Message: "invalid address 0x01"},
+				{Row: 3, Column: 2, Message: "integer 256 out of range for uint8"},
+			},
+		},
+		{
+			"address,int8\n" +
+				"0x0000000000000000000000000000000000000001,-129\n",
+			true,
+			[]RowError{
+				{Row: 2, Column: 2, Message: "integer -129 out of range for int8"},
+			},
+		},
+		{
+			"address,uint\n" +
+				"0x0000000000000000000000000000000000000001\n",
+			false,
+			[]RowError{
+				{Row: 2, Column: 1, Message: "wrong number of fields"},
+			},
+		},
+		{
+			"address,uint7\n",
+			false,
+			[]RowError{
+				{Row: 1, Column: 2, Message: `invalid type "uint7"`},
+			},
+		},
+	}
+	for _, c := range cases {
+		_, _, err := ReadCSV(strings.NewReader(c.csv), c.packed)
+		var cerr *CSVError
+		if !errors.As(err, &cerr) {
+			t.Errorf("%q: expected CSVError got: %v", c.csv, err)
+			continue
+		}
+		if !reflect.DeepEqual(cerr.Rows, c.rows) {
+			t.Errorf("%q: expected: %v got: %v", c.csv, c.rows, cerr.Rows)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Import and export of merkle trees in the JSON dump
+// formats of other libraries.
+//
+//   - [merkletreejs] getHexLayers, see [ToHexLayers] and [FromHexLayers]
+//   - [OpenZeppelin] StandardMerkleTree.dump, see [StandardDump]
+//
+// [merkletreejs]: https://github.com/merkletreejs/merkletreejs
+// [OpenZeppelin]: https://github.com/OpenZeppelin/merkle-tree
+package format
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/contextwtf/lanyard/merkle"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrRootMismatch is returned when the root of an
+// imported tree differs from the root in the dump.
+var ErrRootMismatch = errors.New("format: root does not match dump")
+
+// Returns the levels of t as hex strings. The result matches
+// getHexLayers of a merkletreejs tree created with
+// keccak256, hashLeaves and sortPairs.
+func ToHexLayers(t merkle.Tree) [][]string {
+	layers := make([][]string, 0, len(t))
+	for _, level := range t {
+		l := make([]string, 0, len(level))
+		for _, n := range level {
+			l = append(l, hexutil.Encode(n))
+		}
+		layers = append(layers, l)
+	}
+	return layers
+}
+
+// Builds a tree from leaves and checks that it has the same
+// layers as a merkletreejs getHexLayers dump. The dump only
+// contains hashes so the unhashed leaves must be provided
+// in the same order as the first layer.
+func FromHexLayers(layers [][]string, leaves [][]byte) (merkle.Tree, error) {
+	if len(layers) == 0 || len(layers[0]) == 0 {
+		return nil, errors.New("format: empty layers")
+	}
+	if len(layers[0]) != len(leaves) {
+		return nil, fmt.Errorf("format: %d leaves for %d hashes", len(leaves), len(layers[0]))
+	}
+	for i := range leaves {
+		h, err := hexutil.Decode(layers[0][i])
+		if err != nil {
+			return nil, fmt.Errorf("format: leaf hash %d: %w", i, err)
+		}
+		if !bytes.Equal(h, crypto.Keccak256(leaves[i])) {
+			return nil, fmt.Errorf("format: leaf %d does not match its hash", i)
+		}
+	}
+
+	t := merkle.New(leaves)
+	if len(t) != len(layers) {
+		return nil, ErrRootMismatch
+	}
+	top := layers[len(layers)-1]
+	if len(top) != 1 {
+		return nil, errors.New("format: last layer must contain only the root")
+	}
+	root, err := hexutil.Decode(top[0])
+	if err != nil {
+		return nil, fmt.Errorf("format: root: %w", err)
+	}
+	if !bytes.Equal(root, t.Root()) {
+		return nil, ErrRootMismatch
+	}
+	return t, nil
+}
@@ -0,0 +1,39 @@
+package format
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/contextwtf/lanyard/merkle"
+)
+
+func TestHexLayers(t *testing.T) {
+	leaves := [][]byte{
+		[]byte("a"),
+		[]byte("b"),
+		[]byte("c"),
+		[]byte("d"),
+		[]byte("e"),
+	}
+	layers := ToHexLayers(merkle.New(leaves))
+	if len(layers) != 4 || len(layers[3]) != 1 {
+		t.Fatalf("unexpected layers: %v", layers)
+	}
+
+	tr, err := FromHexLayers(layers, leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr[0]) != len(leaves) {
+		t.Errorf("expected %d leaves got: %d", len(leaves), len(tr[0]))
+	}
+
+	layers[3][0] = layers[2][0]
+	if _, err := FromHexLayers(layers, leaves); !errors.Is(err, ErrRootMismatch) {
+		t.Errorf("expected ErrRootMismatch got: %v", err)
+	}
+
+	if _, err := FromHexLayers(layers, leaves[1:]); err == nil {
+		t.Error("expected error for missing leaf")
+	}
+}
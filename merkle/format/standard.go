@@ -0,0 +1,441 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/contextwtf/lanyard/merkle"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const standardFormat = "standard-v1"
+
+// A StandardDump is the result of OpenZeppelin's
+// StandardMerkleTree.dump(). Leaves are
+// keccak256(keccak256(abi.encode(value))) and the tree is
+// a complete binary tree stored as an array with the root
+// at index 0 and the children of i at 2i+1 and 2i+2.
+//
+// Lanyard trees promote the last node of odd levels instead
+// of filling a complete binary tree, so only some dumps
+// can be converted without changing the root.
+// See [FromStandard].
+type StandardDump struct {
+	Format       string          `json:"format"`
+	LeafEncoding []string        `json:"leafEncoding"`
+	Tree         []hexutil.Bytes `json:"tree"`
+	Values       []StandardValue `json:"values"`
+}
+
+type StandardValue struct {
+	Value     []any `json:"value"`
+	TreeIndex int   `json:"treeIndex"`
+}
+
+// Parses a leaf encoding, such as ["address", "uint256"],
+// into the arguments used to encode and decode leaves.
+func Arguments(leafEncoding []string) (abi.Arguments, error) {
+	if len(leafEncoding) == 0 {
+		return nil, errors.New("format: missing leaf encoding")
+	}
+	var args abi.Arguments
+	for _, desc := range leafEncoding {
+		t, err := abi.NewType(desc, "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("format: leaf encoding %q: %w", desc, err)
+		}
+		args = append(args, abi.Argument{Type: t})
+	}
+	return args, nil
+}
+
+// Converts a JSON value from a dump into the Go
+// type that go-ethereum expects when packing t.
+func toABI(t abi.Type, v any) (any, error) {
+	switch t.T {
+	case abi.AddressTy:
+		s, ok := v.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address %v", v)
+		}
+		return common.HexToAddress(s), nil
+	case abi.BoolTy:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid bool %v", v)
+		}
+		return b, nil
+	case abi.StringTy:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid string %v", v)
+		}
+		return s, nil
+	case abi.BytesTy, abi.FixedBytesTy:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid bytes %v", v)
+		}
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		if t.T == abi.BytesTy {
+			return b, nil
+		}
+		if len(b) != t.Size {
+			return nil, fmt.Errorf("expected %d bytes got %d", t.Size, len(b))
+		}
+		arr := reflect.New(t.GetType()).Elem()
+		reflect.Copy(arr, reflect.ValueOf(b))
+		return arr.Interface(), nil
+	case abi.UintTy, abi.IntTy:
+		var i *big.Int
+		switch n := v.(type) {
+		case string:
+			var ok bool
+			i, ok = math.ParseBig256(n)
+			if !ok {
+				return nil, fmt.Errorf("invalid integer %s", n)
+			}
+		case float64:
+			if n != float64(int64(n)) {
+				return nil, fmt.Errorf("invalid integer %v", n)
+			}
+			i = big.NewInt(int64(n))
+		default:
+			return nil, fmt.Errorf("invalid integer %v", v)
+		}
+		if t.Size > 64 {
+			return i, nil
+		}
+		if (t.T == abi.UintTy && (i.Sign() < 0 || !i.IsUint64())) || (t.T == abi.IntTy && !i.IsInt64()) {
+			return nil, fmt.Errorf("integer %s out of range", i)
+		}
+		rv := reflect.New(t.GetType()).Elem()
+		if t.T == abi.UintTy {
+			rv.SetUint(i.Uint64())
+			if rv.Uint() != i.Uint64() {
+				return nil, fmt.Errorf("integer %s out of range", i)
+			}
+		} else {
+			rv.SetInt(i.Int64())
+			if rv.Int() != i.Int64() {
+				return nil, fmt.Errorf("integer %s out of range", i)
+			}
+		}
+		return rv.Interface(), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// Converts a value unpacked by go-ethereum into
+// the JSON representation used by OpenZeppelin.
+// Addresses are checksummed, integers are base 10
+// strings and bytes are hex.
+func FromABI(v any) any {
+	switch x := v.(type) {
+	case common.Address:
+		return x.Hex()
+	case *big.Int:
+		return x.String()
+	case []byte:
+		return hexutil.Encode(x)
+	case bool, string:
+		return x
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array:
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return hexutil.Encode(b)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%d", rv.Uint())
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%d", rv.Int())
+	}
+	return fmt.Sprint(v)
+}
+
+func encodeValue(args abi.Arguments, value []any) ([]byte, error) {
+	if len(value) != len(args) {
+		return nil, fmt.Errorf("expected %d values got %d", len(args), len(value))
+	}
+	var vals []any
+	for i := range args {
+		v, err := toABI(args[i].Type, value[i])
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+	return args.Pack(vals...)
+}
+
+// Builds a dump the same way as OpenZeppelin's
+// StandardMerkleTree.of(values, leafEncoding).
+func NewStandard(values [][]any, leafEncoding []string) (*StandardDump, error) {
+	if len(values) == 0 {
+		return nil, errors.New("format: no values provided")
+	}
+	args, err := Arguments(leafEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		hashes = make([][]byte, len(values))
+		order  = make([]int, len(values))
+	)
+	for i := range values {
+		enc, err := encodeValue(args, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("format: value %d: %w", i, err)
+		}
+		hashes[i] = crypto.Keccak256(crypto.Keccak256(enc))
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return bytes.Compare(hashes[order[i]], hashes[order[j]]) == -1
+	})
+
+	var (
+		n    = len(values)
+		tree = make([]hexutil.Bytes, 2*n-1)
+		d    = &StandardDump{
+			Format:       standardFormat,
+			LeafEncoding: leafEncoding,
+			Values:       make([]StandardValue, n),
+		}
+	)
+	for pos, i := range order {
+		ti := len(tree) - 1 - pos
+		tree[ti] = hashes[i]
+		d.Values[i] = StandardValue{Value: values[i], TreeIndex: ti}
+	}
+	for i := len(tree) - 1 - n; i >= 0; i-- {
+		tree[i] = merkle.HashPair(tree[2*i+1], tree[2*i+2])
+	}
+	d.Tree = tree
+	return d, nil
+}
+
+func (d *StandardDump) Root() []byte {
+	if len(d.Tree) == 0 {
+		return nil
+	}
+	return d.Tree[0]
+}
+
+// Checks the dump's format, that every value hashes to the
+// leaf at its tree index and that every node is the hash
+// of its children.
+func (d *StandardDump) Verify() error {
+	if d.Format != standardFormat {
+		return fmt.Errorf("format: unknown format %q", d.Format)
+	}
+	args, err := Arguments(d.LeafEncoding)
+	if err != nil {
+		return err
+	}
+	if len(d.Values) == 0 || len(d.Tree) != 2*len(d.Values)-1 {
+		return errors.New("format: tree size does not match values")
+	}
+	firstLeaf := len(d.Tree) - len(d.Values)
+	for i, v := range d.Values {
+		if v.TreeIndex < firstLeaf || v.TreeIndex >= len(d.Tree) {
+			return fmt.Errorf("format: value %d: tree index out of range", i)
+		}
+		enc, err := encodeValue(args, v.Value)
+		if err != nil {
+			return fmt.Errorf("format: value %d: %w", i, err)
+		}
+		if !bytes.Equal(crypto.Keccak256(crypto.Keccak256(enc)), d.Tree[v.TreeIndex]) {
+			return fmt.Errorf("format: value %d does not match its leaf", i)
+		}
+	}
+	for i := firstLeaf - 1; i >= 0; i-- {
+		if !bytes.Equal(merkle.HashPair(d.Tree[2*i+1], d.Tree[2*i+2]), d.Tree[i]) {
+			return fmt.Errorf("format: invalid node at %d", i)
+		}
+	}
+	return nil
+}
+
+// Reports whether a dump of n values has the same shape
+// as a [merkle.Tree] of n leaves.
+func StandardShape(n int) bool {
+	if n < 1 {
+		return false
+	}
+	n /= n & -n // drop the trailing zeros
+	return n&(n+1) == 0
+}
+
+// Returns the tree indexes of a complete binary
+// tree's leaves from left to right.
+func leafOrder(size int) []int {
+	var (
+		order []int
+		walk  func(i int)
+	)
+	walk = func(i int) {
+		if 2*i+1 >= size {
+			order = append(order, i)
+			return
+		}
+		walk(2*i + 1)
+		walk(2*i + 2)
+	}
+	if size > 0 {
+		walk(0)
+	}
+	return order
+}
+
+// Returns unhashed leaves for a [merkle.Tree] in the left
+// to right order of the dump's tree. Each leaf is
+// keccak256(abi.encode(value)) so that hashing it once
+// more, as [merkle.New] does, yields the OpenZeppelin leaf.
+func (d *StandardDump) Leaves() ([][]byte, error) {
+	args, err := Arguments(d.LeafEncoding)
+	if err != nil {
+		return nil, err
+	}
+	byIndex := make(map[int][]any, len(d.Values))
+	for _, v := range d.Values {
+		byIndex[v.TreeIndex] = v.Value
+	}
+	leaves := make([][]byte, 0, len(d.Values))
+	for i, ti := range leafOrder(len(d.Tree)) {
+		v, ok := byIndex[ti]
+		if !ok {
+			return nil, fmt.Errorf("format: missing value for leaf %d", i)
+		}
+		enc, err := encodeValue(args, v)
+		if err != nil {
+			return nil, fmt.Errorf("format: leaf %d: %w", i, err)
+		}
+		leaves = append(leaves, crypto.Keccak256(enc))
+	}
+	return leaves, nil
+}
+
+// Verifies the dump and converts it into a [merkle.Tree]
+// along with the tree's unhashed leaves.
+//
+// A merkle.Tree promotes the last node of an odd level
+// where OpenZeppelin fills a complete binary tree. The
+// shapes, and therefore the roots, only agree when the
+// number of values is a run of ones followed by zeros in
+// binary, e.g. 6, 7, 8, 12, 14, 15 or 16. Use [StandardShape]
+// to check a size. Other dumps return [ErrRootMismatch].
+func FromStandard(d *StandardDump) (merkle.Tree, [][]byte, error) {
+	if err := d.Verify(); err != nil {
+		return nil, nil, err
+	}
+	leaves, err := d.Leaves()
+	if err != nil {
+		return nil, nil, err
+	}
+	t := merkle.New(leaves)
+	if !bytes.Equal(t.Root(), d.Root()) {
+		return nil, nil, ErrRootMismatch
+	}
+	return t, leaves, nil
+}
+
+// Decodes a leaf into one value per argument. Packed
+// encoding has no offsets so only packed leaves of static
+// types, such as address, bool, intN and bytesN, can be
+// decoded.
+func Unpack(args abi.Arguments, leaf []byte, packed bool) ([]any, error) {
+	if !packed {
+		return args.UnpackValues(leaf)
+	}
+	var (
+		vals = make([]any, 0, len(args))
+		pos  int
+	)
+	for _, a := range args {
+		var size int
+		switch a.Type.T {
+		case abi.AddressTy:
+			size = common.AddressLength
+		case abi.BoolTy:
+			size = 1
+		case abi.UintTy, abi.IntTy:
+			size = a.Type.Size / 8
+		case abi.FixedBytesTy:
+			size = a.Type.Size
+		default:
+			return nil, fmt.Errorf("packed %s cannot be decoded", a.Type)
+		}
+		if pos+size > len(leaf) {
+			return nil, errors.New("leaf is too short")
+		}
+		b := leaf[pos : pos+size]
+		pos += size
+
+		switch a.Type.T {
+		case abi.AddressTy:
+			vals = append(vals, common.BytesToAddress(b))
+		case abi.BoolTy:
+			if b[0] > 1 {
+				return nil, fmt.Errorf("invalid bool %d", b[0])
+			}
+			vals = append(vals, b[0] == 1)
+		case abi.UintTy:
+			vals = append(vals, new(big.Int).SetBytes(b))
+		case abi.IntTy:
+			i := new(big.Int).SetBytes(b)
+			if b[0]&0x80 != 0 { // two's complement
+				i.Sub(i, new(big.Int).Lsh(big.NewInt(1), uint(a.Type.Size)))
+			}
+			vals = append(vals, i)
+		default:
+			vals = append(vals, b)
+		}
+	}
+	if pos != len(leaf) {
+		return nil, errors.New("leaf is too long")
+	}
+	return vals, nil
+}
+
+// Decodes leaves described by ltd and builds an
+// OpenZeppelin dump from the values.
+//
+// OpenZeppelin trees hash leaves twice and fill a complete
+// binary tree so the dump's root and proofs differ from
+// the merkle.Tree built from leaves. Contracts verifying
+// the tree must be given the dump's root. Use [ToHexLayers]
+// to export a tree with its own root.
+func ToStandard(leaves [][]byte, ltd []string, packed bool) (*StandardDump, error) {
+	args, err := Arguments(ltd)
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]any, 0, len(leaves))
+	for i, l := range leaves {
+		vals, err := Unpack(args, l, packed)
+		if err != nil {
+			return nil, fmt.Errorf("format: leaf %d: %w", i, err)
+		}
+		v := make([]any, 0, len(vals))
+		for _, val := range vals {
+			v = append(v, FromABI(val))
+		}
+		values = append(values, v)
+	}
+	return NewStandard(values, ltd)
+}
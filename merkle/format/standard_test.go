@@ -0,0 +1,231 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Example from the OpenZeppelin merkle-tree README
+var standardValues = [][]any{
+	{"0x1111111111111111111111111111111111111111", "5000000000000000000"},
+	{"0x2222222222222222222222222222222222222222", "2500000000000000000"},
+}
+
+const standardRoot = "0xd4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77"
+
+func TestNewStandard(t *testing.T) {
+	d, err := NewStandard(standardValues, []string{"address", "uint256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hexutil.Encode(d.Root()) != standardRoot {
+		t.Errorf("expected: %s got: %x", standardRoot, d.Root())
+	}
+	if err := d.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFromStandard(t *testing.T) {
+	d, err := NewStandard(standardValues, []string{"address", "uint256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// round trip through JSON like a real dump
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dump StandardDump
+	if err := json.Unmarshal(b, &dump); err != nil {
+		t.Fatal(err)
+	}
+
+	tr, leaves, err := FromStandard(&dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tr.Root(), dump.Root()) {
+		t.Errorf("expected: %x got: %x", dump.Root(), tr.Root())
+	}
+	if len(leaves) != len(standardValues) {
+		t.Errorf("expected %d leaves got: %d", len(standardValues), len(leaves))
+	}
+
+	dump.Tree[1][0] ^= 1
+	if _, _, err := FromStandard(&dump); err == nil {
+		t.Error("expected error for tampered dump")
+	}
+}
+
+// Proof for the leaf at tree index i of a dump
+// the way OpenZeppelin's getProof walks the array.
+func standardProof(d *StandardDump, i int) [][]byte {
+	var proof [][]byte
+	for i > 0 {
+		sibling := i + 1
+		if i%2 == 0 {
+			sibling = i - 1
+		}
+		proof = append(proof, d.Tree[sibling])
+		i = (i - 1) / 2
+	}
+	return proof
+}
+
+func TestFromStandardShape(t *testing.T) {
+	cases := []struct {
+		n  int
+		ok bool
+	}{
+		{3, true},
+		{5, false},
+		{6, true},
+		{7, true},
+		{10, false},
+		{12, true},
+		{13, false},
+		{15, true},
+		{24, true},
+		{100, false},
+	}
+	for _, c := range cases {
+		var values [][]any
+		for i := 1; i <= c.n; i++ {
+			values = append(values, []any{common.BigToAddress(big.NewInt(int64(i))).Hex(), float64(i)})
+		}
+		d, err := NewStandard(values, []string{"address", "uint256"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dump StandardDump
+		if err := json.Unmarshal(b, &dump); err != nil {
+			t.Fatal(err)
+		}
+
+		if StandardShape(c.n) != c.ok {
+			t.Errorf("%d: expected shape: %t got: %t", c.n, c.ok, !c.ok)
+		}
+		tr, leaves, err := FromStandard(&dump)
+		if !c.ok {
+			if !errors.Is(err, ErrRootMismatch) {
+				t.Errorf("%d: expected ErrRootMismatch got: %v", c.n, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: %v", c.n, err)
+			continue
+		}
+		if !bytes.Equal(tr.Root(), dump.Root()) {
+			t.Errorf("%d: expected: %x got: %x", c.n, dump.Root(), tr.Root())
+		}
+		for i, v := range dump.Values {
+			// the dump's proofs must keep working
+			leaf, err := encodeValue(mustArgs(t, dump.LeafEncoding), v.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaf = crypto.Keccak256(leaf)
+			idx := tr.Index(leaf)
+			if idx < 0 || !bytes.Equal(leaves[idx], leaf) {
+				t.Errorf("%d: value %d not found", c.n, i)
+				continue
+			}
+			got, want := tr.Proof(idx), standardProof(&dump, v.TreeIndex)
+			if len(got) != len(want) {
+				t.Errorf("%d: value %d expected: %x got: %x", c.n, i, want, got)
+				continue
+			}
+			for j := range got {
+				if !bytes.Equal(got[j], want[j]) {
+					t.Errorf("%d: value %d expected: %x got: %x", c.n, i, want, got)
+					break
+				}
+			}
+		}
+	}
+}
+
+func mustArgs(t *testing.T, leafEncoding []string) abi.Arguments {
+	args, err := Arguments(leafEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return args
+}
+
+func TestToStandard(t *testing.T) {
+	leaves := [][]byte{
+		common.FromHex("0x00000000000000000000000011111111111111111111111111111111111111110000000000000000000000000000000000000000000000004563918244f40000"),
+		common.FromHex("0x000000000000000000000000222222222222222222222222222222222222222200000000000000000000000000000000000000000000000022b1c8c1227a0000"),
+	}
+	d, err := ToStandard(leaves, []string{"address", "uint256"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hexutil.Encode(d.Root()) != standardRoot {
+		t.Errorf("expected: %s got: %x", standardRoot, d.Root())
+	}
+
+}
+
+func TestToStandardPacked(t *testing.T) {
+	cases := []struct {
+		leaf  string
+		ltd   []string
+		value []any
+		err   bool
+	}{
+		{
+			"0x11111111111111111111111111111111111111110000000000000000000000000000000000000000000000004563918244f40000",
+			[]string{"address", "uint256"},
+			[]any{"0x1111111111111111111111111111111111111111", "5000000000000000000"},
+			false,
+		},
+		{
+			"0xff01",
+			[]string{"int8", "bool"},
+			[]any{"-1", true},
+			false,
+		},
+		{
+			"0x0102",
+			[]string{"bytes2"},
+			[]any{"0x0102"},
+			false,
+		},
+		{"0x01", []string{"uint16"}, nil, true},
+		{"0x0102", []string{"uint8"}, nil, true},
+		{"0x0102", []string{"string"}, nil, true},
+		{"0x02", []string{"bool"}, nil, true},
+	}
+	for _, c := range cases {
+		leaf := common.FromHex(c.leaf)
+		d, err := ToStandard([][]byte{leaf, leaf}, c.ltd, true)
+		if (err != nil) != c.err {
+			t.Errorf("%s: expected error: %t got: %v", c.leaf, c.err, err)
+			continue
+		}
+		if c.err {
+			continue
+		}
+		if got := d.Values[0].Value; !reflect.DeepEqual(got, c.value) {
+			t.Errorf("%s: expected: %v got: %v", c.leaf, c.value, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package merkle
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// A hasher reuses a single Keccak256 state and writes
+// its output into buffers owned by the caller so that
+// hashing does not allocate.
+type hasher struct {
+	state crypto.KeccakState
+
+	// scratch space for callers that only need
+	// the hash until the hasher is returned
+	buf [64]byte
+}
+
+var hasherPool = sync.Pool{
+	New: func() any {
+		return &hasher{state: crypto.NewKeccakState()}
+	},
+}
+
+func getHasher() *hasher {
+	return hasherPool.Get().(*hasher)
+}
+
+func putHasher(h *hasher) {
+	hasherPool.Put(h)
+}
+
+// Writes the Keccak256 hash of data into dst
+// and returns dst[:32]. dst must be at least 32 bytes.
+func (h *hasher) sum(dst []byte, data ...[]byte) []byte {
+	h.state.Reset()
+	for _, d := range data {
+		h.state.Write(d)
+	}
+	h.state.Read(dst[:32])
+	return dst[:32:32]
+}
+
+// Like [HashPair] but writes the result into dst.
+func (h *hasher) pair(dst, a, b []byte) []byte {
+	if bytes.Compare(a, b) == -1 { // a < b
+		return h.sum(dst, a, b)
+	}
+	return h.sum(dst, b, a)
+}
+
+// Iterates through the level pairwise merging each
+// pair creating a new level that is half the size of
+// the level. All of the new level's hashes share
+// a single allocation.
+func (h *hasher) merge(level [][]byte) [][]byte {
+	var (
+		newLevel = make([][]byte, 0, (len(level)+1)/2)
+		buf      = make([]byte, 32*(len(level)/2))
+	)
+	for i := 0; i < len(level); i += 2 {
+		switch {
+		case i+1 == len(level):
+			// In the case of a level with an odd number of nodes
+			// we leave the parent with a single child.
+			// Some merkle tree designs allow for the parent
+			// to duplicate the child so that it has both children
+			// thus leaving the level with an even number of nodes.
+			// We don't have that requirement yet and if one day we do
+			// this is the spot to change:
+			newLevel = append(newLevel, level[i])
+		default:
+			dst := buf[(i/2)*32:]
+			newLevel = append(newLevel, h.pair(dst, level[i], level[i+1]))
+		}
+	}
+	return newLevel
+}
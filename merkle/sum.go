@@ -0,0 +1,165 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// A node in a [SumTree]. Sum is the total amount
+// of all the leaves beneath the node.
+type SumNode struct {
+	Hash []byte
+	Sum  *big.Int
+}
+
+// A SumTree is a merkle sum tree. Like [Tree], the outer
+// list represents levels in the tree. Each node commits to
+// the hashes and sums of its children so the root fixes both
+// the set of leaves and the total amount.
+//
+// Leaves are (keccak256(item), amount). Intermediary nodes are
+//
+//	hash = keccak256(a.hash, uint256(a.sum), b.hash, uint256(b.sum))
+//	sum  = a.sum + b.sum
+//
+// where the children a and b are sorted by hash.
+type SumTree [][]SumNode
+
+// Returns a complete SumTree using items for the
+// leaves and amounts for their respective sums.
+// Amounts must be non-negative and the total
+// must fit in a uint256.
+func NewSum(items [][]byte, amounts []*big.Int) (SumTree, error) {
+	if len(items) == 0 {
+		return nil, errors.New("merkle: no leaves provided")
+	}
+	if len(items) != len(amounts) {
+		return nil, errors.New("merkle: items and amounts differ in length")
+	}
+
+	var (
+		leaves = make([]SumNode, 0, len(items))
+		total  = new(big.Int)
+	)
+	for i := range items {
+		if amounts[i] == nil || amounts[i].Sign() < 0 {
+			return nil, errors.New("merkle: amounts must be non-negative")
+		}
+		total.Add(total, amounts[i])
+		leaves = append(leaves, SumNode{
+			Hash: crypto.Keccak256(items[i]),
+			Sum:  new(big.Int).Set(amounts[i]),
+		})
+	}
+	if total.BitLen() > 256 {
+		return nil, errors.New("merkle: total amount out of uint256 range")
+	}
+
+	var t SumTree
+	t = append(t, leaves)
+	for {
+		level := t[len(t)-1]
+		if len(level) == 1 { //root node
+			break
+		}
+		t = append(t, sumMerge(level))
+	}
+	return t, nil
+}
+
+func sumPair(a, b SumNode) SumNode {
+	if bytes.Compare(a.Hash, b.Hash) == 1 { // a > b
+		a, b = b, a
+	}
+	sum := new(big.Int).Add(a.Sum, b.Sum)
+	return SumNode{
+		Hash: crypto.Keccak256(
+			a.Hash, math.U256Bytes(new(big.Int).Set(a.Sum)),
+			b.Hash, math.U256Bytes(new(big.Int).Set(b.Sum)),
+		),
+		Sum: sum,
+	}
+}
+
+// See [hasher.merge] for how odd levels are handled.
+func sumMerge(level []SumNode) []SumNode {
+	newLevel := make([]SumNode, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		switch {
+		case i+1 == len(level):
+			newLevel = append(newLevel, level[i])
+		default:
+			newLevel = append(newLevel, sumPair(level[i], level[i+1]))
+		}
+	}
+	return newLevel
+}
+
+func (t SumTree) Root() SumNode {
+	return t[len(t)-1][0]
+}
+
+// Returns the sum of all amounts in the tree.
+func (t SumTree) Total() *big.Int {
+	return new(big.Int).Set(t.Root().Sum)
+}
+
+// Returns the index of the target leaf in the tree.
+// If the target is not a leaf in the tree, returns -1.
+func (t SumTree) Index(target []byte) int {
+	ht := crypto.Keccak256(target)
+	for i, n := range t[0] {
+		if bytes.Equal(ht, n.Hash) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the sibling nodes needed to recompute the root
+// from the leaf at index. For the order of the nodes,
+// see [Tree.Proof]. The result is used in [ValidSum].
+func (t SumTree) Proof(index int) []SumNode {
+	var proof []SumNode
+	for _, level := range t {
+		var i int
+		switch {
+		case index%2 == 0:
+			i = index + 1
+		case index%2 == 1:
+			i = index - 1
+		}
+		if i < len(level) {
+			proof = append(proof, level[i])
+		}
+		index = index / 2
+	}
+	return proof
+}
+
+// Cumulatively merges (target, amount) with each node in the
+// proof and compares the result with root. A valid proof shows
+// that target was committed with amount and that root.Sum
+// is the total of all amounts in the tree.
+func ValidSum(root SumNode, proof []SumNode, target []byte, amount *big.Int) bool {
+	if amount == nil || amount.Sign() < 0 {
+		return false
+	}
+	n := SumNode{Hash: crypto.Keccak256(target), Sum: amount}
+	for i := range proof {
+		// a negative or oversized sibling could be
+		// used to misrepresent the total
+		if proof[i].Sum == nil || proof[i].Sum.Sign() < 0 || proof[i].Sum.BitLen() > 256 {
+			return false
+		}
+		n = sumPair(n, proof[i])
+		if n.Sum.BitLen() > 256 {
+			return false
+		}
+	}
+	return bytes.Equal(n.Hash, root.Hash) && root.Sum != nil && n.Sum.Cmp(root.Sum) == 0
+}
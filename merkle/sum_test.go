@@ -0,0 +1,84 @@
+package merkle
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSumTree(t *testing.T) {
+	var (
+		items = [][]byte{
+			[]byte("a"),
+			[]byte("b"),
+			[]byte("c"),
+			[]byte("d"),
+			[]byte("e"),
+		}
+		amounts = []*big.Int{
+			big.NewInt(1),
+			big.NewInt(2),
+			big.NewInt(3),
+			big.NewInt(4),
+			big.NewInt(5),
+		}
+	)
+	st, err := NewSum(items, amounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Total().Cmp(big.NewInt(15)) != 0 {
+		t.Errorf("expected total 15 got: %s", st.Total())
+	}
+
+	for i, item := range items {
+		if st.Index(item) != i {
+			t.Errorf("incorrect index, expected %d, got %d", i, st.Index(item))
+		}
+		pf := st.Proof(i)
+		if !ValidSum(st.Root(), pf, item, amounts[i]) {
+			t.Errorf("invalid proof for %s", item)
+		}
+		if ValidSum(st.Root(), pf, item, big.NewInt(100)) {
+			t.Errorf("expected proof with wrong amount to be invalid for %s", item)
+		}
+	}
+
+	// claiming a smaller total with the correct hash must fail
+	root := st.Root()
+	root.Sum = big.NewInt(14)
+	if ValidSum(root, st.Proof(0), items[0], amounts[0]) {
+		t.Error("expected proof against wrong total to be invalid")
+	}
+
+	// a negative sibling could hide part of the total
+	pf := st.Proof(0)
+	pf[0] = SumNode{Hash: pf[0].Hash, Sum: big.NewInt(-1)}
+	if ValidSum(st.Root(), pf, items[0], amounts[0]) {
+		t.Error("expected proof with negative sum to be invalid")
+	}
+}
+
+func TestNewSumInvalid(t *testing.T) {
+	cases := []struct {
+		desc    string
+		items   [][]byte
+		amounts []*big.Int
+	}{
+		{"empty", nil, nil},
+		{"length mismatch", [][]byte{[]byte("a")}, nil},
+		{"negative", [][]byte{[]byte("a")}, []*big.Int{big.NewInt(-1)}},
+		{
+			"overflow",
+			[][]byte{[]byte("a"), []byte("b")},
+			[]*big.Int{
+				new(big.Int).Lsh(big.NewInt(1), 255),
+				new(big.Int).Lsh(big.NewInt(1), 255),
+			},
+		},
+	}
+	for _, tc := range cases {
+		if _, err := NewSum(tc.items, tc.amounts); err == nil {
+			t.Errorf("%s: expected error", tc.desc)
+		}
+	}
+}
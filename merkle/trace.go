@@ -0,0 +1,58 @@
+package merkle
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// A single pairwise hash performed while validating a proof.
+type TraceStep struct {
+	// Node is the cumulative hash before this step
+	Node []byte
+
+	// Sibling is the proof element hashed with Node
+	Sibling []byte
+
+	// SiblingFirst is true when Sibling sorts before Node
+	// and is therefore the first input to the hash
+	SiblingFirst bool
+
+	// Output is keccak256 of the sorted inputs
+	Output []byte
+}
+
+// The result of [Trace].
+type ProofTrace struct {
+	LeafHash []byte
+	Steps    []TraceStep
+	Root     []byte
+	Valid    bool
+}
+
+// Performs the same computation as [Valid] and records
+// each intermediate hash. When a proof fails, comparing
+// the steps with those of a known good proof shows where
+// the proof diverges from the path to the root.
+func Trace(root []byte, proof [][]byte, leaf []byte) ProofTrace {
+	var (
+		node = crypto.Keccak256(leaf)
+		pt   = ProofTrace{
+			LeafHash: node,
+			Steps:    make([]TraceStep, 0, len(proof)),
+		}
+	)
+	for i := range proof {
+		out := HashPair(node, proof[i])
+		pt.Steps = append(pt.Steps, TraceStep{
+			Node:         node,
+			Sibling:      proof[i],
+			SiblingFirst: bytes.Compare(node, proof[i]) != -1,
+			Output:       out,
+		})
+		node = out
+	}
+	pt.Root = node
+	pt.Valid = bytes.Equal(node, root)
+	return pt
+}
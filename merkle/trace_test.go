@@ -0,0 +1,46 @@
+package merkle
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	leaves := [][]byte{
+		[]byte("a"),
+		[]byte("b"),
+		[]byte("c"),
+		[]byte("d"),
+		[]byte("e"),
+	}
+	mt := New(leaves)
+	pf := mt.Proof(2)
+
+	pt := Trace(mt.Root(), pf, leaves[2])
+	if !pt.Valid {
+		t.Fatal("expected valid trace")
+	}
+	if len(pt.Steps) != len(pf) {
+		t.Fatalf("expected %d steps got: %d", len(pf), len(pt.Steps))
+	}
+	if !bytes.Equal(pt.Steps[len(pt.Steps)-1].Output, mt.Root()) {
+		t.Error("expected last output to be the root")
+	}
+	for i, s := range pt.Steps {
+		if i > 0 && !bytes.Equal(s.Node, pt.Steps[i-1].Output) {
+			t.Errorf("step %d does not continue from previous output", i)
+		}
+		if s.SiblingFirst != (bytes.Compare(s.Sibling, s.Node) == -1) {
+			t.Errorf("step %d has incorrect sort order", i)
+		}
+	}
+
+	pf[1] = pf[0]
+	pt = Trace(mt.Root(), pf, leaves[2])
+	if pt.Valid {
+		t.Error("expected invalid trace")
+	}
+	if Valid(mt.Root(), pf, leaves[2]) != pt.Valid {
+		t.Error("expected Trace to agree with Valid")
+	}
+}
@@ -12,8 +12,6 @@ package merkle
 
 import (
 	"bytes"
-
-	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // A the outer list represents levels in the tree. Each level is a list
@@ -23,9 +21,14 @@ type Tree [][][]byte
 // Returns a complete Tree using items for the leaves.
 // Intermediary nodes and items will be hashed using Keccak256.
 func New(items [][]byte) Tree {
-	var leaves [][]byte
+	var (
+		h      = getHasher()
+		leaves = make([][]byte, len(items))
+		buf    = make([]byte, 32*len(items))
+	)
+	defer putHasher(h)
 	for i := range items {
-		leaves = append(leaves, crypto.Keccak256(items[i]))
+		leaves[i] = h.sum(buf[i*32:], items[i])
 	}
 	var t Tree
 	t = append(t, leaves)
@@ -35,39 +38,26 @@ func New(items [][]byte) Tree {
 		if len(level) == 1 { //root node
 			break
 		}
-		t = append(t, hashMerge(level))
+		t = append(t, h.merge(level))
 	}
 	return t
 }
 
-func hashPair(a, b []byte) []byte {
-	if bytes.Compare(a, b) == -1 { // a < b
-		return crypto.Keccak256(a, b)
-	}
-	return crypto.Keccak256(b, a)
+// Returns the Keccak256 hash of a and b in sorted
+// order, the way every parent node in a Tree is hashed.
+func HashPair(a, b []byte) []byte {
+	h := getHasher()
+	defer putHasher(h)
+	return h.pair(make([]byte, 32), a, b)
 }
 
 // Iterates through the level pairwise merging each
 // pair with a hash function creating a new level that
 // is half the size of the level.
 func hashMerge(level [][]byte) [][]byte {
-	newLevel := make([][]byte, 0, (len(level)+1)/2)
-	for i := 0; i < len(level); i += 2 {
-		switch {
-		case i+1 == len(level):
-			// In the case of a level with an odd number of nodes
-			// we leave the parent with a single child.
-			// Some merkle tree designs allow for the parent
-			// to duplicate the child so that it has both children
-			// thus leaving the level with an even number of nodes.
-			// We don't have that requirement yet and if one day we do
-			// this is the spot to change:
-			newLevel = append(newLevel, level[i])
-		default:
-			newLevel = append(newLevel, hashPair(level[i], level[i+1]))
-		}
-	}
-	return newLevel
+	h := getHasher()
+	defer putHasher(h)
+	return h.merge(level)
 }
 
 func (t Tree) Root() []byte {
@@ -77,7 +67,9 @@ func (t Tree) Root() []byte {
 // Returns the index of the target leaf in the tree.
 // If the target is not a leaf in the tree, returns -1.
 func (t Tree) Index(target []byte) int {
-	ht := crypto.Keccak256(target)
+	hs := getHasher()
+	defer putHasher(hs)
+	ht := hs.sum(hs.buf[:], target)
 	for i, h := range t[0] {
 		if bytes.Equal(ht, h) {
 			return i
@@ -103,7 +95,11 @@ func (t Tree) Index(target []byte) int {
 //
 // The result of this func will be used in [Valid]
 func (t Tree) Proof(index int) [][]byte {
-	var proof [][]byte
+	return t.appendProof(nil, index)
+}
+
+// Appends the proof for index to proof.
+func (t Tree) appendProof(proof [][]byte, index int) [][]byte {
 	for _, level := range t {
 		var i int
 		switch {
@@ -123,10 +119,18 @@ func (t Tree) Proof(index int) [][]byte {
 // Returns proofs for all leafs in the tree.
 // For details on how an individual proof is calculated, see [Tree.Proof].
 func (t Tree) LeafProofs() [][][]byte {
-	proofs := make([][][]byte, len(t[0]))
+	var (
+		proofs = make([][][]byte, len(t[0]))
+		// a proof has at most one hash per level below
+		// the root so all proofs can share one allocation
+		depth = len(t) - 1
+		buf   = make([][]byte, 0, len(t[0])*depth)
+	)
 
 	for i := range t[0] {
-		proofs[i] = t.Proof(i)
+		p := t.appendProof(buf, i)
+		proofs[i] = p[len(buf):len(p):len(p)]
+		buf = p
 	}
 
 	return proofs
@@ -135,9 +139,17 @@ func (t Tree) LeafProofs() [][][]byte {
 // Cumulatively hashes the list pairwise starting with
 // (target, proof[0]). Finally, the cumulative hash is compared with the root.
 func Valid(root []byte, proof [][]byte, target []byte) bool {
-	target = crypto.Keccak256(target)
+	var (
+		h = getHasher()
+		// alternate between two buffers since
+		// the previous hash is an input to the next
+		a, b = h.buf[:32], h.buf[32:]
+	)
+	defer putHasher(h)
+	target = h.sum(a, target)
 	for i := range proof {
-		target = hashPair(target, proof[i])
+		target = h.pair(b, target, proof[i])
+		a, b = b, a
 	}
 	return bytes.Equal(target, root)
 }
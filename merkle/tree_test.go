@@ -127,6 +127,7 @@ func BenchmarkNew(b *testing.B) {
 	for i := 0; i < 50000; i++ {
 		leaves = append(leaves, []byte{byte(i)})
 	}
+	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
 		New(leaves)
@@ -158,7 +159,26 @@ func BenchmarkProofs(b *testing.B) {
 		leaves = append(leaves, []byte{byte(i)})
 	}
 	mt := New(leaves)
+	b.ReportAllocs()
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		mt.LeafProofs()
 	}
 }
+
+func BenchmarkValid(b *testing.B) {
+	var leaves [][]byte
+	for i := 0; i < 50000; i++ {
+		leaves = append(leaves, []byte{byte(i)})
+	}
+	var (
+		mt   = New(leaves)
+		root = mt.Root()
+		pf   = mt.Proof(len(leaves) / 2)
+	)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Valid(root, pf, leaves[len(leaves)/2])
+	}
+}
@@ -0,0 +1,130 @@
+package merkle
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+var ErrLeafNotFound = errors.New("merkle: leaf not found in tree")
+
+// A LeafEncoder converts a value into the unhashed
+// leaf bytes that are stored in a [Tree].
+type LeafEncoder[T any] interface {
+	EncodeLeaf(v T) ([]byte, error)
+}
+
+// LeafEncoderFunc allows an ordinary function
+// to be used as a [LeafEncoder].
+type LeafEncoderFunc[T any] func(v T) ([]byte, error)
+
+func (f LeafEncoderFunc[T]) EncodeLeaf(v T) ([]byte, error) {
+	return f(v)
+}
+
+// Encodes an address as its 20 raw bytes.
+// This matches abi.encodePacked(address).
+type AddressEncoder struct{}
+
+func (AddressEncoder) EncodeLeaf(a common.Address) ([]byte, error) {
+	return a.Bytes(), nil
+}
+
+// An address and the quantity it is allowed to claim.
+type AddressQuantity struct {
+	Address  common.Address
+	Quantity *big.Int
+}
+
+// Encodes an [AddressQuantity] as (address, uint256).
+// When Packed is true the result matches abi.encodePacked,
+// otherwise it matches abi.encode.
+type AddressQuantityEncoder struct {
+	Packed bool
+}
+
+func (e AddressQuantityEncoder) EncodeLeaf(aq AddressQuantity) ([]byte, error) {
+	q := aq.Quantity
+	if q == nil {
+		q = new(big.Int)
+	}
+	if q.Sign() < 0 || q.BitLen() > 256 {
+		return nil, errors.New("merkle: quantity out of uint256 range")
+	}
+	var addr []byte
+	if e.Packed {
+		addr = aq.Address.Bytes()
+	} else {
+		addr = common.LeftPadBytes(aq.Address.Bytes(), 32)
+	}
+	return append(addr, math.U256Bytes(new(big.Int).Set(q))...), nil
+}
+
+// A TypedTree is a [Tree] built from native Go values.
+// Values are converted to unhashed leaves using a [LeafEncoder]
+// and the underlying Tree is constructed with [New].
+type TypedTree[T any] struct {
+	Tree    Tree
+	encoder LeafEncoder[T]
+}
+
+// Encodes each of the items with enc and returns
+// a TypedTree using the encoded items as leaves.
+func NewTyped[T any](enc LeafEncoder[T], items []T) (*TypedTree[T], error) {
+	leaves := make([][]byte, 0, len(items))
+	for i := range items {
+		l, err := enc.EncodeLeaf(items[i])
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, l)
+	}
+	return &TypedTree[T]{
+		Tree:    New(leaves),
+		encoder: enc,
+	}, nil
+}
+
+func (t *TypedTree[T]) Root() []byte {
+	return t.Tree.Root()
+}
+
+// Returns the unhashed leaf for v.
+func (t *TypedTree[T]) Leaf(v T) ([]byte, error) {
+	return t.encoder.EncodeLeaf(v)
+}
+
+// Returns the index of v in the tree.
+// If v is not a leaf in the tree, returns -1.
+func (t *TypedTree[T]) Index(v T) (int, error) {
+	l, err := t.encoder.EncodeLeaf(v)
+	if err != nil {
+		return -1, err
+	}
+	return t.Tree.Index(l), nil
+}
+
+// Returns the proof for v. If v is not a leaf in
+// the tree, [ErrLeafNotFound] is returned.
+// For details on how the proof is calculated, see [Tree.Proof].
+func (t *TypedTree[T]) Proof(v T) ([][]byte, error) {
+	i, err := t.Index(v)
+	if err != nil {
+		return nil, err
+	}
+	if i == -1 {
+		return nil, ErrLeafNotFound
+	}
+	return t.Tree.Proof(i), nil
+}
+
+// Reports whether proof proves that v is a leaf in the tree.
+func (t *TypedTree[T]) Valid(proof [][]byte, v T) bool {
+	l, err := t.encoder.EncodeLeaf(v)
+	if err != nil {
+		return false
+	}
+	return Valid(t.Root(), proof, l)
+}
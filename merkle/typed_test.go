@@ -0,0 +1,100 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func ExampleTypedTree() {
+	var addrs = []common.Address{
+		common.HexToAddress("0xE124F06277b5AC791bA45B92853BA9A0ea93327D"),
+		common.HexToAddress("0x07d048f78B7C093B3Ef27D478B78026a70D9734e"),
+		common.HexToAddress("0x38976611f5f7bEAd7e79E752f5B80AE72dD3eFa7"),
+		common.HexToAddress("0x1Ab00ffedD724B930080aD30269083F1453cF34E"),
+		common.HexToAddress("0x860a6bC426C3bb1186b2E11Ac486ABa000C209B4"),
+		common.HexToAddress("0x0B3eC21fc53AD8b17AF4A80723c1496541fCb35f"),
+		common.HexToAddress("0x2D13F6CEe6dA8b30a84ee7954594925bd5E47Ab7"),
+		common.HexToAddress("0x3C64Cd43331beb5B6fAb76dbAb85226955c5CC3A"),
+		common.HexToAddress("0x238dA873f984188b4F4c7efF03B5580C65a49dcB"),
+		common.HexToAddress("0xbAfC038aDfd8BcF6E632C797175A057714416d04"),
+	}
+	tr, err := NewTyped[common.Address](AddressEncoder{}, addrs)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(common.Bytes2Hex(tr.Root()))
+
+	// Output:
+	// ed40d49077a2cd13601cf79a512e6b92c7fd0f952e7dc9f4758d7134f9712bc4
+}
+
+func TestAddressQuantityEncoder(t *testing.T) {
+	cases := []struct {
+		packed bool
+		aq     AddressQuantity
+		want   []byte
+	}{
+		{
+			true,
+			AddressQuantity{
+				common.HexToAddress("0x0000000000000000000000000000000000000001"),
+				new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18)),
+			},
+			common.FromHex("0x00000000000000000000000000000000000000010000000000000000000000000000000000000000000000008ac7230489e80000"),
+		},
+		{
+			false,
+			AddressQuantity{
+				common.HexToAddress("0x0000000000000000000000000000000000000001"),
+				big.NewInt(45),
+			},
+			common.FromHex("0x0000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000002d"),
+		},
+	}
+
+	for _, c := range cases {
+		got, err := AddressQuantityEncoder{Packed: c.packed}.EncodeLeaf(c.aq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, c.want) {
+			t.Errorf("expected: %x got: %x", c.want, got)
+		}
+	}
+
+	_, err := AddressQuantityEncoder{}.EncodeLeaf(AddressQuantity{Quantity: big.NewInt(-1)})
+	if err == nil {
+		t.Error("expected error for negative quantity")
+	}
+}
+
+func TestTypedTreeProof(t *testing.T) {
+	items := []AddressQuantity{
+		{common.HexToAddress("0x01"), big.NewInt(1)},
+		{common.HexToAddress("0x02"), big.NewInt(2)},
+		{common.HexToAddress("0x03"), big.NewInt(3)},
+	}
+	tr, err := NewTyped[AddressQuantity](AddressQuantityEncoder{Packed: true}, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, it := range items {
+		pf, err := tr.Proof(it)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tr.Valid(pf, it) {
+			t.Error("invalid proof")
+		}
+	}
+
+	_, err = tr.Proof(AddressQuantity{common.HexToAddress("0x01"), big.NewInt(2)})
+	if !errors.Is(err, ErrLeafNotFound) {
+		t.Errorf("expected ErrLeafNotFound, got %v", err)
+	}
+}